@@ -3,7 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
@@ -12,104 +12,135 @@ import (
 	"go.uber.org/zap"
 )
 
-// Fetch and parse access entries from EKS API.
+// getAccessEntriesMappings maps the Kubernetes usernames and groups of the access entry snapshot to principal ARNs.
 func (c *EKSClient) getAccessEntriesMappings(ctx context.Context) (map[string][]string, map[string][]string, error) {
+	snapshot, err := c.AccessEntries(ctx, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	userMap, groupMap := accessEntryMappings(ctx, snapshot.Entries())
+	return userMap, groupMap, nil
+}
+
+// accessEntryMappings maps the Kubernetes usernames and groups of standard access entries to principal ARNs.
+func accessEntryMappings(ctx context.Context, accessEntries []*AccessEntry) (map[string][]string, map[string][]string) {
 	l := ctxzap.Extract(ctx)
 	userMap := make(map[string][]string)  // k8s username -> AWS user ARN list
 	groupMap := make(map[string][]string) // k8s group -> AWS user ARN list
 
-	// List all access entries for the cluster
-	paginator := eks.NewListAccessEntriesPaginator(c.eksClient, &eks.ListAccessEntriesInput{
-		ClusterName: aws.String(c.clusterName),
-	})
-
-	var accessEntries []string
-	for paginator.HasMorePages() {
-		page, err := paginator.NextPage(ctx)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to list access entries: %w", err)
-		}
-		accessEntries = append(accessEntries, page.AccessEntries...)
-	}
-
-	// Process each access entry.
-	// The accessEntryArn is the ARN of the IAM user or role in the access entry, not the access entry ARN.
-	for _, accessEntryArn := range accessEntries {
-		if !isUserOrRoleArn(accessEntryArn) {
+	for _, accessEntry := range accessEntries {
+		// The principal ARN is the ARN of the IAM user or role in the access entry, not the access entry ARN.
+		principalArn := accessEntry.PrincipalArn
+		if !isUserOrRoleArn(principalArn) {
 			l.Debug("Skipping non-user/role ARN",
-				zap.String("principal_arn", accessEntryArn))
-			continue
-		}
-
-		// Get detailed information about the access entry
-		describeResult, err := c.eksClient.DescribeAccessEntry(ctx, &eks.DescribeAccessEntryInput{
-			ClusterName:  aws.String(c.clusterName),
-			PrincipalArn: aws.String(accessEntryArn),
-		})
-		if err != nil {
-			// Skip this access entry if we can't describe it
+				zap.String("principal_arn", principalArn))
 			continue
 		}
 
-		accessEntry := describeResult.AccessEntry
-		if accessEntry == nil {
+		// Only process STANDARD type access entries (not node types)
+		if accessEntry.Type != "STANDARD" {
 			continue
 		}
 
-		// // Only process STANDARD type access entries (not node types)
-		if accessEntry.Type == nil || *accessEntry.Type != "STANDARD" {
-			continue
-		}
-
-		// For Access Entries, the principal ARN is the primary identity
-		principalArn := *accessEntry.PrincipalArn
-
-		// Map username if present, otherwise use principal ARN as username
-		if accessEntry.Username != nil && *accessEntry.Username != "" {
-			// Use the specified username
-			users := userMap[*accessEntry.Username]
-			userMap[*accessEntry.Username] = append(users, principalArn)
-		} else {
-			// Use the principal ARN as the username (Access Entries style)
-			users := userMap[principalArn]
-			userMap[principalArn] = append(users, principalArn)
+		// Map username if present, otherwise use principal ARN as username (Access Entries style)
+		username := accessEntry.Username
+		if username == "" {
+			username = principalArn
 		}
+		userMap[username] = append(userMap[username], principalArn)
 
 		// Map groups if present (these come from Kubernetes RBAC if specified)
-		if accessEntry.KubernetesGroups != nil {
-			for _, group := range accessEntry.KubernetesGroups {
-				groups := groupMap[group]
-				groupMap[group] = append(groups, principalArn)
-			}
+		for _, group := range accessEntry.KubernetesGroups {
+			groupMap[group] = append(groupMap[group], principalArn)
 		}
 	}
 
-	return userMap, groupMap, nil
+	return userMap, groupMap
 }
 
-// GetAccessEntriesWithPolicy retrieves principal ARNs that have a specific policy assigned.
-func (c *EKSClient) GetAccessEntriesWithPolicy(ctx context.Context, policyARN string, nextToken *string) ([]string, *string, error) {
-	var principalARNs []string
+// DescribeAccessEntry retrieves the access entry of a principal.
+func (c *EKSClient) DescribeAccessEntry(ctx context.Context, principalARN string) (*AccessEntry, error) {
+	describeResult, err := c.eksClient.DescribeAccessEntry(ctx, &eks.DescribeAccessEntryInput{
+		ClusterName:  aws.String(c.clusterName),
+		PrincipalArn: aws.String(principalARN),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe access entry: %w", err)
+	}
+	if describeResult.AccessEntry == nil {
+		return nil, fmt.Errorf("access entry for %s not found", principalARN)
+	}
 
-	input := &eks.ListAccessEntriesInput{
-		ClusterName:         aws.String(c.clusterName),
-		AssociatedPolicyArn: aws.String(policyARN),
-		MaxResults:          aws.Int32(100),
+	entry := describeResult.AccessEntry
+	accessEntry := &AccessEntry{
+		AccessEntryArn:   aws.ToString(entry.AccessEntryArn),
+		ClusterName:      aws.ToString(entry.ClusterName),
+		KubernetesGroups: entry.KubernetesGroups,
+		PrincipalArn:     aws.ToString(entry.PrincipalArn),
+		Tags:             entry.Tags,
+		Type:             aws.ToString(entry.Type),
+		Username:         aws.ToString(entry.Username),
+	}
+	if entry.CreatedAt != nil {
+		accessEntry.CreatedAt = entry.CreatedAt.Format(time.RFC3339)
 	}
+	if entry.ModifiedAt != nil {
+		accessEntry.ModifiedAt = entry.ModifiedAt.Format(time.RFC3339)
+	}
+	return accessEntry, nil
+}
 
-	// Add nextToken if provided
-	if nextToken != nil && *nextToken != "" {
-		input.NextToken = nextToken
+// CheckAccessEntryAccess reads a single access entry and its access policies, to check that the caller
+// can read the access entries of the cluster without listing all of them.
+func (c *EKSClient) CheckAccessEntryAccess(ctx context.Context) error {
+	listResult, err := c.eksClient.ListAccessEntries(ctx, &eks.ListAccessEntriesInput{
+		ClusterName: aws.String(c.clusterName),
+		MaxResults:  aws.Int32(1),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list access entries: %w", err)
+	}
+	if len(listResult.AccessEntries) == 0 {
+		return nil
 	}
 
-	page, err := c.eksClient.ListAccessEntries(ctx, input)
+	principalARN := listResult.AccessEntries[0]
+	if _, err := c.DescribeAccessEntry(ctx, principalARN); err != nil {
+		return err
+	}
+	_, err = c.eksClient.ListAssociatedAccessPolicies(ctx, &eks.ListAssociatedAccessPoliciesInput{
+		ClusterName:  aws.String(c.clusterName),
+		PrincipalArn: aws.String(principalARN),
+		MaxResults:   aws.Int32(1),
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to list access entries: %w", err)
+		return fmt.Errorf("failed to list associated access policies: %w", err)
 	}
+	return nil
+}
 
-	principalARNs = append(principalARNs, page.AccessEntries...)
+// ListAccessPolicies retrieves every access policy offered by EKS.
+func (c *EKSClient) ListAccessPolicies(ctx context.Context) ([]*AccessPolicy, error) {
+	var policies []*AccessPolicy
 
-	return principalARNs, page.NextToken, nil
+	paginator := eks.NewListAccessPoliciesPaginator(c.eksClient, &eks.ListAccessPoliciesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list access policies: %w", err)
+		}
+		for _, policy := range page.AccessPolicies {
+			if policy.Arn == nil || policy.Name == nil {
+				continue
+			}
+			policies = append(policies, &AccessPolicy{
+				PolicyARN:   *policy.Arn,
+				DisplayName: *policy.Name,
+			})
+		}
+	}
+
+	return policies, nil
 }
 
 // GetAssociatedAccessPolicies retrieves the associated access policies for a principal with their scopes.
@@ -133,18 +164,47 @@ func (c *EKSClient) GetAssociatedAccessPolicies(ctx context.Context, principalAR
 	return allPolicies, nil
 }
 
+// CreateAccessEntry creates a standard access entry that maps the principal to its ARN as Kubernetes username.
+// The default username EKS picks for roles is templated by session, so roles couldn't be bound by RBAC otherwise.
 func (c *EKSClient) CreateAccessEntry(ctx context.Context, principalARN string) (*eksTypes.AccessEntry, error) {
-	accessEntry, err := c.eksClient.CreateAccessEntry(ctx, &eks.CreateAccessEntryInput{
-		ClusterName:  aws.String(c.clusterName),
-		PrincipalArn: aws.String(principalARN),
-		Type:         aws.String("STANDARD"),
-	})
+	return c.CreateAccessEntryWithMapping(ctx, principalARN, principalARN, nil)
+}
+
+// CreateAccessEntryWithMapping creates a standard access entry that maps the principal to Kubernetes groups,
+// and to a username unless it's empty, in which case EKS picks the default username of the principal.
+func (c *EKSClient) CreateAccessEntryWithMapping(ctx context.Context, principalARN string, username string, groups []string) (*eksTypes.AccessEntry, error) {
+	input := &eks.CreateAccessEntryInput{
+		ClusterName:      aws.String(c.clusterName),
+		PrincipalArn:     aws.String(principalARN),
+		Type:             aws.String("STANDARD"),
+		KubernetesGroups: groups,
+	}
+	if username != "" {
+		input.Username = aws.String(username)
+	}
+
+	accessEntry, err := c.eksClient.CreateAccessEntry(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create access entry: %w", err)
 	}
+	c.invalidateAccessEntries()
 	return accessEntry.AccessEntry, nil
 }
 
+// UpdateAccessEntryGroups replaces the Kubernetes groups of the principal's access entry.
+func (c *EKSClient) UpdateAccessEntryGroups(ctx context.Context, principalARN string, groups []string) error {
+	_, err := c.eksClient.UpdateAccessEntry(ctx, &eks.UpdateAccessEntryInput{
+		ClusterName:      aws.String(c.clusterName),
+		PrincipalArn:     aws.String(principalARN),
+		KubernetesGroups: groups,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update access entry: %w", err)
+	}
+	c.invalidateAccessEntries()
+	return nil
+}
+
 // AssociateAccessPolicy associates an access policy with a specific scope.
 func (c *EKSClient) AssociateAccessPolicy(ctx context.Context, principalARN string, policyARN string, accessScope *eksTypes.AccessScope) error {
 	_, err := c.eksClient.AssociateAccessPolicy(ctx, &eks.AssociateAccessPolicyInput{
@@ -156,6 +216,7 @@ func (c *EKSClient) AssociateAccessPolicy(ctx context.Context, principalARN stri
 	if err != nil {
 		return fmt.Errorf("failed to associate access policy: %w", err)
 	}
+	c.invalidateAccessEntries()
 	return nil
 }
 
@@ -168,20 +229,11 @@ func (c *EKSClient) DisassociateAccessPolicy(ctx context.Context, principalARN s
 	if err != nil {
 		return fmt.Errorf("failed to disassociate access policy: %w", err)
 	}
+	c.invalidateAccessEntries()
 	return nil
 }
 
-// isUserOrRoleArn checks if an ARN is for an IAM user or role.
+// isUserOrRoleArn checks if an ARN is for an IAM user or role, in any partition.
 func isUserOrRoleArn(arn string) bool {
-	// AWS ARN format: arn:aws:service:region:account:resource-type/resource-name
-	// For IAM users: arn:aws:iam::account:user/username
-	// For IAM roles: arn:aws:iam::account:role/rolename
-
-	// Check if it's an IAM ARN
-	if !strings.HasPrefix(arn, "arn:aws:iam::") {
-		return false
-	}
-
-	// Check if it's a user or role
-	return strings.Contains(arn, ":user/") || strings.Contains(arn, ":role/")
+	return IsIAMUserARN(arn) || IsIAMRoleARN(arn)
 }
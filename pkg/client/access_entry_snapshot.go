@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultAccessEntryConcurrency bounds the concurrent calls describing access entries and their associated access policies.
+	DefaultAccessEntryConcurrency = 8
+	// accessEntryMaxAttempts bounds the attempts of a throttled call.
+	accessEntryMaxAttempts = 8
+	throttleBaseDelay      = 200 * time.Millisecond
+	throttleMaxDelay       = 20 * time.Second
+)
+
+// AccessEntrySnapshot is a snapshot of the access entries of a cluster with their associated access policies.
+type AccessEntrySnapshot struct {
+	entries     []*AccessEntry
+	byPrincipal map[string]*AccessEntry
+	// failures are the errors of the entries that couldn't be fully described, by principal ARN.
+	failures map[string]error
+}
+
+// NewAccessEntrySnapshot indexes the access entries by principal ARN.
+func NewAccessEntrySnapshot(entries []*AccessEntry) *AccessEntrySnapshot {
+	snapshot := &AccessEntrySnapshot{
+		entries:     make([]*AccessEntry, 0, len(entries)),
+		byPrincipal: make(map[string]*AccessEntry, len(entries)),
+		failures:    make(map[string]error),
+	}
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		snapshot.entries = append(snapshot.entries, entry)
+		snapshot.byPrincipal[entry.PrincipalArn] = entry
+	}
+	sort.Slice(snapshot.entries, func(i, j int) bool {
+		return snapshot.entries[i].PrincipalArn < snapshot.entries[j].PrincipalArn
+	})
+	return snapshot
+}
+
+// Entries returns the access entries, sorted by principal ARN.
+func (s *AccessEntrySnapshot) Entries() []*AccessEntry {
+	return s.entries
+}
+
+// Entry returns the access entry of the principal.
+func (s *AccessEntrySnapshot) Entry(principalARN string) (*AccessEntry, bool) {
+	entry, ok := s.byPrincipal[principalARN]
+	return entry, ok
+}
+
+// Failures returns the errors of the entries that couldn't be described, or whose associated access policies
+// couldn't be listed, by principal ARN. Entries whose access policies couldn't be listed are part of the snapshot.
+func (s *AccessEntrySnapshot) Failures() map[string]error {
+	return s.failures
+}
+
+// PolicyScope returns the scope the access policy is associated with, if it's associated to the entry.
+// Associations that don't report a scope are scoped to the cluster.
+func (e *AccessEntry) PolicyScope(policyARN string) (*eksTypes.AccessScope, bool) {
+	for _, policy := range e.AssociatedPolicies {
+		if aws.ToString(policy.PolicyArn) != policyARN {
+			continue
+		}
+		if policy.AccessScope == nil {
+			return &eksTypes.AccessScope{Type: eksTypes.AccessScopeTypeCluster}, true
+		}
+		return policy.AccessScope, true
+	}
+	return nil, false
+}
+
+// AccessEntries returns the snapshot of the cluster's access entries, taking it if there is none yet or refresh is set.
+// The snapshot is refreshed at the start of each sync, so that every builder reads the same access entries
+// without describing them again.
+func (c *EKSClient) AccessEntries(ctx context.Context, refresh bool) (*AccessEntrySnapshot, error) {
+	c.accessEntriesMutex.Lock()
+	defer c.accessEntriesMutex.Unlock()
+
+	if c.accessEntries != nil && !refresh {
+		return c.accessEntries, nil
+	}
+
+	var principalARNs []string
+	paginator := eks.NewListAccessEntriesPaginator(c.eksClient, &eks.ListAccessEntriesInput{
+		ClusterName: aws.String(c.clusterName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list access entries: %w", err)
+		}
+		principalARNs = append(principalARNs, page.AccessEntries...)
+	}
+
+	entries := make([]*AccessEntry, len(principalARNs))
+	errs := make([]error, len(principalARNs))
+	backoff := &adaptiveBackoff{}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(c.accessEntryConcurrency, len(principalARNs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entries[i], errs[i] = c.describeAccessEntryWithPolicies(ctx, backoff, principalARNs[i])
+			}
+		}()
+	}
+	for i := range principalARNs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	snapshot := NewAccessEntrySnapshot(entries)
+	for i, err := range errs {
+		if err != nil {
+			snapshot.failures[principalARNs[i]] = err
+		}
+	}
+	c.accessEntries = snapshot
+	return c.accessEntries, nil
+}
+
+// describeAccessEntryWithPolicies describes the access entry of the principal with its associated access policies,
+// retrying throttled calls. It returns the entry without access policies and an error if they can't be listed,
+// and neither if the entry was deleted since it was listed.
+func (c *EKSClient) describeAccessEntryWithPolicies(ctx context.Context, backoff *adaptiveBackoff, principalARN string) (*AccessEntry, error) {
+	var entry *AccessEntry
+	err := backoff.retry(ctx, func() error {
+		var err error
+		entry, err = c.DescribeAccessEntry(ctx, principalARN)
+		return err
+	})
+	if err != nil {
+		var notFoundErr *eksTypes.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = backoff.retry(ctx, func() error {
+		var err error
+		entry.AssociatedPolicies, err = c.GetAssociatedAccessPolicies(ctx, principalARN)
+		return err
+	})
+	if err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// adaptiveBackoff is the delay shared by the workers taking a snapshot. It doubles each time a call is throttled
+// and halves each time a call succeeds, so that the workers slow down together while EKS throttles them.
+type adaptiveBackoff struct {
+	mtx   sync.Mutex
+	delay time.Duration
+}
+
+// retry calls fn after the current delay until it succeeds, fails with an error other than throttling,
+// or runs out of attempts.
+func (b *adaptiveBackoff) retry(ctx context.Context, fn func() error) error {
+	var err error
+	for range accessEntryMaxAttempts {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+		err = fn()
+		if !isThrottlingError(err) {
+			if err == nil {
+				b.succeeded()
+			}
+			return err
+		}
+		ctxzap.Extract(ctx).Debug("EKS call throttled, backing off", zap.Duration("delay", b.throttled()))
+	}
+	return err
+}
+
+// wait sleeps for the current delay, with jitter so that the workers don't retry in lockstep.
+func (b *adaptiveBackoff) wait(ctx context.Context) error {
+	b.mtx.Lock()
+	delay := b.delay
+	b.mtx.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay/2 + rand.N(delay/2+1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttled doubles the delay and returns it.
+func (b *adaptiveBackoff) throttled() time.Duration {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.delay = min(max(b.delay*2, throttleBaseDelay), throttleMaxDelay)
+	return b.delay
+}
+
+// succeeded halves the delay, dropping it once it's below the base delay.
+func (b *adaptiveBackoff) succeeded() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.delay /= 2
+	if b.delay < throttleBaseDelay {
+		b.delay = 0
+	}
+}
+
+func isThrottlingError(err error) bool {
+	var throttlingErr *eksTypes.ThrottlingException
+	return errors.As(err, &throttlingErr)
+}
+
+// invalidateAccessEntries drops the snapshot after the access entries were changed,
+// so that the next read takes a new one.
+func (c *EKSClient) invalidateAccessEntries() {
+	c.accessEntriesMutex.Lock()
+	defer c.accessEntriesMutex.Unlock()
+	c.accessEntries = nil
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessEntrySnapshot(t *testing.T) {
+	snapshot := NewAccessEntrySnapshot([]*AccessEntry{
+		{
+			PrincipalArn: "arn:aws:iam::123456789012:user/bob",
+			AssociatedPolicies: []eksTypes.AssociatedAccessPolicy{
+				{PolicyArn: aws.String("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")},
+			},
+		},
+		// Entries that couldn't be described are left out.
+		nil,
+		{
+			PrincipalArn: "arn:aws:iam::123456789012:role/admin",
+			AssociatedPolicies: []eksTypes.AssociatedAccessPolicy{
+				{
+					PolicyArn:   aws.String("arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminPolicy"),
+					AccessScope: &eksTypes.AccessScope{Type: eksTypes.AccessScopeTypeNamespace, Namespaces: []string{"dev"}},
+				},
+			},
+		},
+	})
+
+	entries := snapshot.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/admin", entries[0].PrincipalArn)
+	assert.Equal(t, "arn:aws:iam::123456789012:user/bob", entries[1].PrincipalArn)
+
+	admin, ok := snapshot.Entry("arn:aws:iam::123456789012:role/admin")
+	require.True(t, ok)
+	scope, ok := admin.PolicyScope("arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminPolicy")
+	require.True(t, ok)
+	assert.Equal(t, []string{"dev"}, scope.Namespaces)
+	_, ok = admin.PolicyScope("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")
+	assert.False(t, ok)
+
+	bob, ok := snapshot.Entry("arn:aws:iam::123456789012:user/bob")
+	require.True(t, ok)
+	scope, ok = bob.PolicyScope("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")
+	require.True(t, ok)
+	assert.Equal(t, eksTypes.AccessScopeTypeCluster, scope.Type)
+
+	_, ok = snapshot.Entry("arn:aws:iam::123456789012:user/alice")
+	assert.False(t, ok)
+}
+
+func TestAccessEntryMappings(t *testing.T) {
+	userMap, groupMap := accessEntryMappings(context.Background(), []*AccessEntry{
+		{
+			PrincipalArn:     "arn:aws:iam::123456789012:role/admin",
+			Type:             "STANDARD",
+			Username:         "admin",
+			KubernetesGroups: []string{"admins"},
+		},
+		{
+			PrincipalArn:     "arn:aws:iam::123456789012:user/bob",
+			Type:             "STANDARD",
+			KubernetesGroups: []string{"admins", "viewers"},
+		},
+		{
+			PrincipalArn: "arn:aws:iam::123456789012:role/node",
+			Type:         "EC2_LINUX",
+		},
+	})
+
+	assert.Equal(t, map[string][]string{
+		"admin":                              {"arn:aws:iam::123456789012:role/admin"},
+		"arn:aws:iam::123456789012:user/bob": {"arn:aws:iam::123456789012:user/bob"},
+	}, userMap)
+	assert.Equal(t, map[string][]string{
+		"admins":  {"arn:aws:iam::123456789012:role/admin", "arn:aws:iam::123456789012:user/bob"},
+		"viewers": {"arn:aws:iam::123456789012:user/bob"},
+	}, groupMap)
+}
+
+func TestAdaptiveBackoff(t *testing.T) {
+	backoff := &adaptiveBackoff{}
+
+	assert.Equal(t, throttleBaseDelay, backoff.throttled())
+	assert.Equal(t, 2*throttleBaseDelay, backoff.throttled())
+	for range 10 {
+		backoff.throttled()
+	}
+	assert.Equal(t, throttleMaxDelay, backoff.delay)
+
+	backoff.delay = 2 * throttleBaseDelay
+	backoff.succeeded()
+	assert.Equal(t, throttleBaseDelay, backoff.delay)
+	backoff.succeeded()
+	assert.Zero(t, backoff.delay)
+}
+
+func TestAdaptiveBackoff_Retry(t *testing.T) {
+	throttlingErr := fmt.Errorf("failed to describe access entry: %w", &eksTypes.ThrottlingException{Message: aws.String("Rate exceeded")})
+
+	t.Run("retries throttled calls", func(t *testing.T) {
+		backoff := &adaptiveBackoff{}
+		calls := 0
+		err := backoff.retry(context.Background(), func() error {
+			calls++
+			if calls < 3 {
+				return throttlingErr
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		// The delay recovers as calls succeed.
+		assert.Equal(t, throttleBaseDelay, backoff.delay)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		backoff := &adaptiveBackoff{}
+		calls := 0
+		err := backoff.retry(context.Background(), func() error {
+			calls++
+			return errors.New("access denied")
+		})
+		require.EqualError(t, err, "access denied")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		backoff := &adaptiveBackoff{delay: throttleMaxDelay}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := backoff.retry(ctx, func() error {
+			t.Fatal("unexpected call")
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// DefaultPartition is the partition of the commercial AWS regions.
+const DefaultPartition = "aws"
+
+// regionPartitions maps region prefixes to the partitions outside the commercial one.
+// Longer prefixes come first, as us-isob- and us-isof- also start with us-iso.
+var regionPartitions = []struct {
+	prefix    string
+	partition string
+}{
+	{"us-isob-", "aws-iso-b"},
+	{"us-isof-", "aws-iso-f"},
+	{"eu-isoe-", "aws-iso-e"},
+	{"us-iso-", "aws-iso"},
+	{"us-gov-", "aws-us-gov"},
+	{"cn-", "aws-cn"},
+	{"eusc-", "aws-eusc"},
+}
+
+// PartitionForRegion returns the AWS partition of a region, such as aws-us-gov for us-gov-west-1.
+func PartitionForRegion(region string) string {
+	for _, p := range regionPartitions {
+		if strings.HasPrefix(region, p.prefix) {
+			return p.partition
+		}
+	}
+	return DefaultPartition
+}
+
+// PartitionOf returns the partition of an ARN, or the commercial partition if it isn't an ARN.
+func PartitionOf(s string) string {
+	parsed, err := arn.Parse(s)
+	if err != nil || parsed.Partition == "" {
+		return DefaultPartition
+	}
+	return parsed.Partition
+}
+
+// IAMPrincipal is a parsed IAM principal ARN, such as arn:aws-us-gov:iam::123456789012:role/team/deployer.
+type IAMPrincipal struct {
+	Partition string
+	AccountID string
+	// Type is the resource type of the principal: user, role or root.
+	Type string
+	// Path is the IAM path of a user or role, such as /team/, or / when it has none.
+	Path string
+	Name string
+}
+
+// ParseIAMPrincipalARN parses the ARN of an IAM user, role or account root in any partition.
+func ParseIAMPrincipalARN(s string) (*IAMPrincipal, error) {
+	parsed, err := arn.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARN %s: %w", s, err)
+	}
+	if parsed.Service != "iam" || parsed.AccountID == "" {
+		return nil, fmt.Errorf("%s isn't the ARN of an IAM principal", s)
+	}
+	if parsed.Resource == "root" {
+		return &IAMPrincipal{Partition: parsed.Partition, AccountID: parsed.AccountID, Type: "root"}, nil
+	}
+
+	principalType, resource, ok := strings.Cut(parsed.Resource, "/")
+	if !ok || resource == "" || (principalType != "user" && principalType != "role") {
+		return nil, fmt.Errorf("%s isn't the ARN of an IAM user or role", s)
+	}
+	principalPath := path.Dir("/" + resource)
+	if principalPath != "/" {
+		principalPath += "/"
+	}
+	return &IAMPrincipal{
+		Partition: parsed.Partition,
+		AccountID: parsed.AccountID,
+		Type:      principalType,
+		Path:      principalPath,
+		Name:      path.Base(resource),
+	}, nil
+}
+
+// String returns the ARN of the principal.
+func (p *IAMPrincipal) String() string {
+	if p.Type == "root" {
+		return fmt.Sprintf("arn:%s:iam::%s:root", p.Partition, p.AccountID)
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:%s%s%s", p.Partition, p.AccountID, p.Type, p.Path, p.Name)
+}
+
+// IsIAMUserARN reports whether s is the ARN of an IAM user, in any partition.
+func IsIAMUserARN(s string) bool {
+	principal, err := ParseIAMPrincipalARN(s)
+	return err == nil && principal.Type == "user"
+}
+
+// IsIAMRoleARN reports whether s is the ARN of an IAM role, in any partition.
+func IsIAMRoleARN(s string) bool {
+	principal, err := ParseIAMPrincipalARN(s)
+	return err == nil && principal.Type == "role"
+}
+
+// CallerPrincipalARN returns the IAM principal of a caller identity ARN, as returned by sts:GetCallerIdentity.
+// Assumed role sessions map to their role, without its path, which session ARNs don't carry.
+func CallerPrincipalARN(callerARN string) (string, error) {
+	parsed, err := arn.Parse(callerARN)
+	if err != nil {
+		return "", fmt.Errorf("invalid ARN %s: %w", callerARN, err)
+	}
+	if parsed.Service == "sts" {
+		roleName, _, ok := strings.Cut(strings.TrimPrefix(parsed.Resource, "assumed-role/"), "/")
+		if !strings.HasPrefix(parsed.Resource, "assumed-role/") || !ok || roleName == "" {
+			return "", fmt.Errorf("%s isn't the ARN of an assumed role session", callerARN)
+		}
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", parsed.Partition, parsed.AccountID, roleName), nil
+	}
+	principal, err := ParseIAMPrincipalARN(callerARN)
+	if err != nil {
+		return "", err
+	}
+	return principal.String(), nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionForRegion(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":       "aws",
+		"eu-west-1":       "aws",
+		"us-gov-west-1":   "aws-us-gov",
+		"cn-north-1":      "aws-cn",
+		"cn-northwest-1":  "aws-cn",
+		"us-iso-east-1":   "aws-iso",
+		"us-isob-east-1":  "aws-iso-b",
+		"eu-isoe-west-1":  "aws-iso-e",
+		"us-isof-south-1": "aws-iso-f",
+		"":                "aws",
+	}
+	for region, want := range tests {
+		assert.Equal(t, want, PartitionForRegion(region), region)
+	}
+}
+
+func TestParseIAMPrincipalARN(t *testing.T) {
+	principal, err := ParseIAMPrincipalARN("arn:aws-us-gov:iam::123456789012:role/teams/platform/deployer")
+	require.NoError(t, err)
+	assert.Equal(t, &IAMPrincipal{
+		Partition: "aws-us-gov",
+		AccountID: "123456789012",
+		Type:      "role",
+		Path:      "/teams/platform/",
+		Name:      "deployer",
+	}, principal)
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:role/teams/platform/deployer", principal.String())
+
+	principal, err = ParseIAMPrincipalARN("arn:aws-cn:iam::123456789012:user/alice")
+	require.NoError(t, err)
+	assert.Equal(t, "user", principal.Type)
+	assert.Equal(t, "/", principal.Path)
+	assert.Equal(t, "arn:aws-cn:iam::123456789012:user/alice", principal.String())
+
+	principal, err = ParseIAMPrincipalARN("arn:aws-iso:iam::123456789012:root")
+	require.NoError(t, err)
+	assert.Equal(t, "root", principal.Type)
+	assert.Equal(t, "arn:aws-iso:iam::123456789012:root", principal.String())
+
+	for _, invalid := range []string{
+		"",
+		"123456789012",
+		"arn:aws:sts::123456789012:assumed-role/admin/session",
+		"arn:aws:eks:us-east-1:123456789012:cluster/prod",
+		"arn:aws:iam::123456789012:group/admins",
+		"arn:aws:iam::123456789012:role/",
+	} {
+		_, err := ParseIAMPrincipalARN(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestIsIAMUserOrRoleARN(t *testing.T) {
+	assert.True(t, IsIAMUserARN("arn:aws-us-gov:iam::123456789012:user/alice"))
+	assert.False(t, IsIAMUserARN("arn:aws-us-gov:iam::123456789012:role/admin"))
+	assert.True(t, IsIAMRoleARN("arn:aws-us-gov:iam::123456789012:role/admin"))
+	assert.False(t, IsIAMRoleARN("arn:aws:sts::123456789012:assumed-role/admin/session"))
+	assert.True(t, isUserOrRoleArn("arn:aws-cn:iam::123456789012:role/admin"))
+	assert.False(t, isUserOrRoleArn("arn:aws-cn:iam::123456789012:root"))
+}
+
+func TestCallerPrincipalARN(t *testing.T) {
+	for callerARN, expected := range map[string]string{
+		"arn:aws:sts::123456789012:assumed-role/baton/session":         "arn:aws:iam::123456789012:role/baton",
+		"arn:aws-us-gov:sts::123456789012:assumed-role/baton/i-0abc12": "arn:aws-us-gov:iam::123456789012:role/baton",
+		"arn:aws:iam::123456789012:user/ops/baton":                     "arn:aws:iam::123456789012:user/ops/baton",
+	} {
+		principalARN, err := CallerPrincipalARN(callerARN)
+		require.NoError(t, err, callerARN)
+		assert.Equal(t, expected, principalARN)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"arn:aws:sts::123456789012:federated-user/alice",
+		"arn:aws:sts::123456789012:assumed-role/baton",
+	} {
+		_, err := CallerPrincipalARN(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
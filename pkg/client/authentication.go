@@ -2,105 +2,205 @@ package client
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
 	awsV2 "github.com/aws/aws-sdk-go-v2/aws"
-	awsV1 "github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/sts"
-	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"golang.org/x/sync/singleflight"
 )
 
+const (
+	// eksTokenExpiryMargin is how long before its expiration a token is refreshed.
+	eksTokenExpiryMargin = time.Minute
+	// eksTokenLifetime is how long after it was signed a token is used. EKS accepts tokens signed up to 15 minutes
+	// earlier whatever their X-Amz-Expires, so tokens are considered expired a minute before EKS rejects them.
+	eksTokenLifetime = 14 * time.Minute
+
+	eksTokenPrefix        = "k8s-aws-v1."
+	eksClusterIDHeader    = "x-k8s-aws-id"
+	eksTokenPresignExpiry = "60"
+	amzDateFormat         = "20060102T150405Z"
+)
+
+// EKSToken is a bearer token for the Kubernetes API of an EKS cluster.
+type EKSToken struct {
+	Token      string
+	Expiration time.Time
+}
+
 // EksTokenRefreshRoundTripper is a custom http.RoundTripper that refreshes EKS tokens.
+// Requests share the cached token concurrently; only refreshing it is serialized.
 type EksTokenRefreshRoundTripper struct {
 	ClusterID     string
 	Region        string
 	AssumeRoleARN string
 	Base          http.RoundTripper // The underlying HTTP transport
-	token         string
-	mu            sync.Mutex
-	exp           time.Time    // Expiration time of the token
-	AwsConfig     awsV2.Config // AWS config with assumed role credentials
+	AwsConfig     awsV2.Config      // AWS config with assumed role credentials
+
+	// generate overrides the token generator in tests.
+	generate func(ctx context.Context) (EKSToken, error)
+	mu       sync.RWMutex
+	token    EKSToken
+	refresh  singleflight.Group
 }
 
 func (t *EksTokenRefreshRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	// Check if token is expired or not yet generated
-	// EKS tokens last for ~15 minutes. We can generate a new one proactively.
-	if t.token == "" || time.Until(t.exp) < 1*time.Minute {
-		newToken, err := GenerateEKSTokenWithCredentials(req.Context(), t.ClusterID, t.Region, t.AwsConfig)
+	ctx := req.Context()
+	token, err := t.currentToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Base.RoundTrip(withBearerToken(req, token.Token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token was rejected before its expiration, such as after the credentials it was signed with were rotated.
+	// Retry once with a new token, if the request body can be sent again.
+	retryReq := req
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := req.GetBody()
 		if err != nil {
-			return nil, fmt.Errorf("failed to refresh EKS token: %w", err)
+			return resp, nil
 		}
-		t.token = newToken
-		t.exp = time.Now().Add(15 * time.Minute) // Set expiration for 15 minutes
+		retryReq = req.Clone(ctx)
+		retryReq.Body = body
 	}
 
-	req.Header.Set("Authorization", "Bearer "+t.token)
-	return t.Base.RoundTrip(req)
-}
-
-// generateEKSToken generates a short-lived authentication token for EKS.
-func GenerateEKSToken(clusterID string, region string, assumeRoleARN string) (string, error) {
-	gen, err := token.NewGenerator(false, false) // `false, false` for disable_sts_regional_endpoints, disable_credential_cache
+	token, err = t.refreshToken(ctx, token.Token)
 	if err != nil {
-		return "", fmt.Errorf("failed to create EKS token generator: %w", err)
+		return resp, nil
 	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
 
-	opts := &token.GetTokenOptions{
-		ClusterID:     clusterID,
-		Region:        region,
-		AssumeRoleARN: assumeRoleARN,
+	return t.Base.RoundTrip(withBearerToken(retryReq, token.Token))
+}
+
+// currentToken returns the cached token, refreshing it if there is none yet or it's about to expire.
+func (t *EksTokenRefreshRoundTripper) currentToken(ctx context.Context) (EKSToken, error) {
+	t.mu.RLock()
+	token := t.token
+	t.mu.RUnlock()
+
+	if token.Token != "" && time.Until(token.Expiration) > eksTokenExpiryMargin {
+		return token, nil
 	}
+	return t.refreshToken(ctx, token.Token)
+}
 
-	tk, err := gen.GetWithOptions(opts)
+// refreshToken replaces the stale token with a new one, unless a concurrent refresh already replaced it.
+// Concurrent refreshes share a single call to the token generator.
+func (t *EksTokenRefreshRoundTripper) refreshToken(ctx context.Context, stale string) (EKSToken, error) {
+	v, err, _ := t.refresh.Do("token", func() (interface{}, error) {
+		t.mu.RLock()
+		current := t.token
+		t.mu.RUnlock()
+		if current.Token != "" && current.Token != stale && time.Until(current.Expiration) > eksTokenExpiryMargin {
+			return current, nil
+		}
+
+		// The token is shared by the requests waiting for it, so one of them being canceled mustn't fail the others.
+		token, err := t.generateToken(context.WithoutCancel(ctx))
+		if err != nil {
+			return EKSToken{}, err
+		}
+
+		t.mu.Lock()
+		t.token = token
+		t.mu.Unlock()
+		return token, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get EKS token: %w", err)
+		return EKSToken{}, fmt.Errorf("failed to refresh EKS token: %w", err)
 	}
+	return v.(EKSToken), nil
+}
 
-	return tk.Token, nil
+func (t *EksTokenRefreshRoundTripper) generateToken(ctx context.Context) (EKSToken, error) {
+	if t.generate != nil {
+		return t.generate(ctx)
+	}
+	return GenerateEKSTokenWithCredentials(ctx, t.ClusterID, t.Region, t.AwsConfig)
+}
+
+// withBearerToken returns a copy of the request that is authenticated with the token.
+// Round trippers mustn't modify the requests they are given.
+func withBearerToken(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
 }
 
 // GenerateEKSTokenWithCredentials generates a short-lived authentication token for EKS using provided AWS credentials.
-func GenerateEKSTokenWithCredentials(ctx context.Context, clusterID string, region string, awsConfig awsV2.Config) (string, error) {
-	// Retrieve credentials from the AWS v2 config
-	creds, err := awsConfig.Credentials.Retrieve(ctx)
+// The token is a presigned STS GetCallerIdentity URL for the cluster. STS is called in the cluster's region,
+// and its endpoint follows the region's partition and the FIPS and dual-stack settings of the config.
+func GenerateEKSTokenWithCredentials(ctx context.Context, clusterID string, region string, awsConfig awsV2.Config) (EKSToken, error) {
+	stsClient := sts.NewFromConfig(awsConfig, func(o *sts.Options) {
+		o.Region = region
+	})
+
+	presigned, err := sts.NewPresignClient(stsClient).PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{},
+		func(o *sts.PresignOptions) {
+			o.ClientOptions = append(o.ClientOptions, func(o *sts.Options) {
+				o.APIOptions = append(o.APIOptions, addEKSTokenParameters(clusterID))
+			})
+		})
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve credentials: %w", err)
+		return EKSToken{}, fmt.Errorf("failed to presign EKS token: %w", err)
 	}
 
-	// Create AWS v1 session with the credentials
-	sess, err := session.NewSession(&awsV1.Config{
-		Region: awsV1.String(region),
-		Credentials: credentials.NewStaticCredentials(
-			creds.AccessKeyID,
-			creds.SecretAccessKey,
-			creds.SessionToken,
-		),
-	})
+	signedAt, err := presignedURLSigningTime(presigned.URL)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AWS session: %w", err)
+		return EKSToken{}, err
 	}
 
-	// Create v1 STS client
-	stsClient := sts.New(sess)
+	return EKSToken{
+		Token:      eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presigned.URL)),
+		Expiration: signedAt.Add(eksTokenLifetime),
+	}, nil
+}
 
-	// Create the token generator
-	gen, err := token.NewGenerator(false, false)
+// presignedURLSigningTime returns when the presigned URL was signed, from its X-Amz-Date.
+func presignedURLSigningTime(presignedURL string) (time.Time, error) {
+	u, err := url.Parse(presignedURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to create EKS token generator: %w", err)
+		return time.Time{}, fmt.Errorf("failed to parse presigned EKS token URL: %w", err)
 	}
-
-	// Generate token using the v1 STS client
-	tk, err := gen.GetWithSTS(clusterID, stsClient)
+	signedAt, err := time.Parse(amzDateFormat, u.Query().Get("X-Amz-Date"))
 	if err != nil {
-		return "", fmt.Errorf("failed to get EKS token: %w", err)
+		return time.Time{}, fmt.Errorf("failed to parse signing time of EKS token: %w", err)
 	}
+	return signedAt, nil
+}
 
-	return tk.Token, nil
+// addEKSTokenParameters signs the cluster ID header into the presigned request, which EKS checks against the cluster,
+// and sets the expiry of the request as aws-iam-authenticator does.
+func addEKSTokenParameters(clusterID string) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("EKSTokenParameters",
+			func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+				req, ok := in.Request.(*smithyhttp.Request)
+				if !ok {
+					return middleware.BuildOutput{}, middleware.Metadata{}, fmt.Errorf("unexpected request type %T", in.Request)
+				}
+				req.Header.Set(eksClusterIDHeader, clusterID)
+				query := req.URL.Query()
+				query.Set("X-Amz-Expires", eksTokenPresignExpiry)
+				req.URL.RawQuery = query.Encode()
+				return next.HandleBuild(ctx, in)
+			}), middleware.After)
+	}
 }
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// tokenGenerator generates numbered tokens that expire after ttl.
+type tokenGenerator struct {
+	ttl   time.Duration
+	calls atomic.Int32
+}
+
+func (g *tokenGenerator) generate(ctx context.Context) (EKSToken, error) {
+	n := g.calls.Add(1)
+	return EKSToken{Token: fmt.Sprintf("token-%d", n), Expiration: time.Now().Add(g.ttl)}, nil
+}
+
+func newTestResponse(req *http.Request, status int) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: req}
+}
+
+func TestEksTokenRefreshRoundTripper_ConcurrentRequests(t *testing.T) {
+	const requests = 8
+	gen := &tokenGenerator{ttl: 14 * time.Minute}
+
+	// Every request blocks until all of them are in flight, which deadlocks if requests are serialized.
+	var inFlight sync.WaitGroup
+	inFlight.Add(requests)
+	rt := &EksTokenRefreshRoundTripper{
+		generate: gen.generate,
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+			inFlight.Done()
+			inFlight.Wait()
+			return newTestResponse(req, http.StatusOK), nil
+		}),
+	}
+
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodGet, "https://cluster.example.com/api", nil)
+			require.NoError(t, err)
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			// The caller's request is left unchanged.
+			assert.Empty(t, req.Header.Get("Authorization"))
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("requests were serialized")
+	}
+	assert.Equal(t, int32(1), gen.calls.Load())
+}
+
+func TestEksTokenRefreshRoundTripper_Expiration(t *testing.T) {
+	// The token expires within the refresh margin, so every request refreshes it.
+	gen := &tokenGenerator{ttl: 30 * time.Second}
+	rt := &EksTokenRefreshRoundTripper{
+		generate: gen.generate,
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return newTestResponse(req, http.StatusOK), nil
+		}),
+	}
+
+	for range 2 {
+		req, err := http.NewRequest(http.MethodGet, "https://cluster.example.com/api", nil)
+		require.NoError(t, err)
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), gen.calls.Load())
+
+	gen.ttl = 14 * time.Minute
+	for range 2 {
+		req, err := http.NewRequest(http.MethodGet, "https://cluster.example.com/api", nil)
+		require.NoError(t, err)
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), gen.calls.Load())
+}
+
+func TestEksTokenRefreshRoundTripper_Unauthorized(t *testing.T) {
+	gen := &tokenGenerator{ttl: 14 * time.Minute}
+	var authorizations []string
+	var bodies []string
+	rt := &EksTokenRefreshRoundTripper{
+		generate: gen.generate,
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			authorizations = append(authorizations, req.Header.Get("Authorization"))
+			if req.Body != nil {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				bodies = append(bodies, string(body))
+			}
+			// Only the second token is accepted.
+			if req.Header.Get("Authorization") != "Bearer token-2" {
+				return newTestResponse(req, http.StatusUnauthorized), nil
+			}
+			return newTestResponse(req, http.StatusOK), nil
+		}),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://cluster.example.com/api", strings.NewReader(`{"kind":"ConfigMap"}`))
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, authorizations)
+	assert.Equal(t, []string{`{"kind":"ConfigMap"}`, `{"kind":"ConfigMap"}`}, bodies)
+
+	// A token that is rejected again is only retried once.
+	gen.calls.Store(5)
+	rt.token = EKSToken{}
+	authorizations = nil
+	req, err = http.NewRequest(http.MethodGet, "https://cluster.example.com/api", nil)
+	require.NoError(t, err)
+	resp, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer token-6", "Bearer token-7"}, authorizations)
+}
+
+func TestGenerateEKSTokenWithCredentials(t *testing.T) {
+	credentialsProvider := credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "session-token")
+
+	tests := []struct {
+		name     string
+		region   string
+		opts     []func(*awsConfig.LoadOptions) error
+		wantHost string
+	}{
+		{
+			name:     "regional endpoint",
+			region:   "eu-west-1",
+			wantHost: "sts.eu-west-1.amazonaws.com",
+		},
+		{
+			name:     "china partition",
+			region:   "cn-north-1",
+			wantHost: "sts.cn-north-1.amazonaws.com.cn",
+		},
+		{
+			name:     "fips endpoint",
+			region:   "us-east-1",
+			opts:     []func(*awsConfig.LoadOptions) error{awsConfig.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled)},
+			wantHost: "sts-fips.us-east-1.amazonaws.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := awsConfig.LoadDefaultConfig(context.Background(), append([]func(*awsConfig.LoadOptions) error{
+				awsConfig.WithRegion("us-east-1"),
+				awsConfig.WithCredentialsProvider(credentialsProvider),
+			}, tt.opts...)...)
+			require.NoError(t, err)
+
+			before := time.Now()
+			token, err := GenerateEKSTokenWithCredentials(context.Background(), "my-cluster", tt.region, cfg)
+			require.NoError(t, err)
+			assert.WithinDuration(t, before.Add(eksTokenLifetime), token.Expiration, time.Minute)
+
+			require.True(t, strings.HasPrefix(token.Token, "k8s-aws-v1."))
+			presignedURL, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token.Token, "k8s-aws-v1."))
+			require.NoError(t, err)
+			u, err := url.Parse(string(presignedURL))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantHost, u.Host)
+			query := u.Query()
+			assert.Equal(t, "GetCallerIdentity", query.Get("Action"))
+			assert.Equal(t, "60", query.Get("X-Amz-Expires"))
+			assert.Equal(t, "session-token", query.Get("X-Amz-Security-Token"))
+			// The token expires relative to when it was signed.
+			signedAt, err := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+			require.NoError(t, err)
+			assert.Equal(t, signedAt.Add(eksTokenLifetime), token.Expiration)
+			assert.Contains(t, strings.Split(query.Get("X-Amz-SignedHeaders"), ";"), "x-k8s-aws-id")
+			assert.Contains(t, query.Get("X-Amz-Credential"), "/"+tt.region+"/sts/")
+		})
+	}
+}
@@ -0,0 +1,453 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+// awsAuthManagedAnnotation is the aws-auth ConfigMap annotation that lists, as a JSON array,
+// the ARNs of the mappings the connector created. Only those are removed when they're no longer used.
+const awsAuthManagedAnnotation = "baton.conductorone.com/managed-mappings"
+
+// AWSAuthMapping is the mapping of an IAM user or role in the aws-auth ConfigMap.
+type AWSAuthMapping struct {
+	ARN      string
+	Username string
+	Groups   []string
+}
+
+// AWSAuthRoleARN returns the ARN of an IAM role the way the aws-auth ConfigMap matches it, without the role path.
+// The ARNs of other principals are returned unchanged.
+func AWSAuthRoleARN(roleArn string) string {
+	role, err := ParseIAMPrincipalARN(roleArn)
+	if err != nil || role.Type != "role" {
+		return roleArn
+	}
+	role.Path = "/"
+	return role.String()
+}
+
+// awsAuthMappings is the mapUsers or mapRoles list of the aws-auth ConfigMap. Entries are kept as generic maps,
+// so that fields the connector doesn't know about survive edits.
+type awsAuthMappings struct {
+	key      string
+	arnField string
+	entries  []map[string]interface{}
+	changed  bool
+}
+
+func parseAWSAuthMappings(data map[string]string, key string, arnField string) (*awsAuthMappings, error) {
+	m := &awsAuthMappings{key: key, arnField: arnField}
+	if value := data[key]; strings.TrimSpace(value) != "" {
+		if err := yaml.Unmarshal([]byte(value), &m.entries); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+		}
+	}
+	return m, nil
+}
+
+// find returns the index of the principal's entry, or -1. Roles are matched without their paths.
+func (m *awsAuthMappings) find(principalArn string) int {
+	want := AWSAuthRoleARN(principalArn)
+	return slices.IndexFunc(m.entries, func(entry map[string]interface{}) bool {
+		arn, _ := entry[m.arnField].(string)
+		return arn != "" && AWSAuthRoleARN(arn) == want
+	})
+}
+
+func (m *awsAuthMappings) mapping(i int) *AWSAuthMapping {
+	entry := m.entries[i]
+	mapping := &AWSAuthMapping{}
+	mapping.ARN, _ = entry[m.arnField].(string)
+	mapping.Username, _ = entry["username"].(string)
+	groups, _ := entry["groups"].([]interface{})
+	for _, group := range groups {
+		if g, ok := group.(string); ok {
+			mapping.Groups = append(mapping.Groups, g)
+		}
+	}
+	return mapping
+}
+
+// set writes the username and groups of the mapping to the entry, leaving its other fields untouched.
+func (m *awsAuthMappings) set(i int, mapping *AWSAuthMapping) {
+	entry := m.entries[i]
+	entry[m.arnField] = mapping.ARN
+	if mapping.Username == "" {
+		delete(entry, "username")
+	} else {
+		entry["username"] = mapping.Username
+	}
+	if len(mapping.Groups) == 0 {
+		delete(entry, "groups")
+	} else {
+		groups := make([]interface{}, 0, len(mapping.Groups))
+		for _, group := range mapping.Groups {
+			groups = append(groups, group)
+		}
+		entry["groups"] = groups
+	}
+	m.changed = true
+}
+
+// awsAuthDocument is the parsed content of the aws-auth ConfigMap. Only the lists that change are written back,
+// so mapAccounts and any other keys are preserved as they are.
+type awsAuthDocument struct {
+	users *awsAuthMappings
+	roles *awsAuthMappings
+	// managed holds the ARNs of the mappings the connector created, as aws-auth matches them.
+	managed        map[string]bool
+	managedChanged bool
+}
+
+func parseAWSAuthDocument(data map[string]string, annotations map[string]string) (*awsAuthDocument, error) {
+	users, err := parseAWSAuthMappings(data, "mapUsers", "userarn")
+	if err != nil {
+		return nil, err
+	}
+	roles, err := parseAWSAuthMappings(data, "mapRoles", "rolearn")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &awsAuthDocument{users: users, roles: roles, managed: make(map[string]bool)}
+	if value := annotations[awsAuthManagedAnnotation]; value != "" {
+		var managed []string
+		if err := json.Unmarshal([]byte(value), &managed); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s annotation: %w", awsAuthManagedAnnotation, err)
+		}
+		for _, arn := range managed {
+			doc.managed[arn] = true
+		}
+	}
+	return doc, nil
+}
+
+// mappingsFor returns the list the principal is mapped in: mapRoles for IAM roles, mapUsers for IAM users.
+func (d *awsAuthDocument) mappingsFor(principalArn string) (*awsAuthMappings, error) {
+	principal, err := ParseIAMPrincipalARN(principalArn)
+	if err != nil {
+		return nil, err
+	}
+	switch principal.Type {
+	case "role":
+		return d.roles, nil
+	case "user":
+		return d.users, nil
+	default:
+		return nil, fmt.Errorf("%s can't be mapped in the aws-auth ConfigMap", principalArn)
+	}
+}
+
+// get returns the mapping of the principal, or nil if it isn't mapped.
+func (d *awsAuthDocument) get(principalArn string) (*AWSAuthMapping, error) {
+	mappings, err := d.mappingsFor(principalArn)
+	if err != nil {
+		return nil, err
+	}
+	i := mappings.find(principalArn)
+	if i < 0 {
+		return nil, nil
+	}
+	return mappings.mapping(i), nil
+}
+
+// add appends a mapping for the principal, and records it as created by the connector.
+// Role ARNs are mapped without their paths, as aws-auth requires.
+func (d *awsAuthDocument) add(mapping AWSAuthMapping) error {
+	mappings, err := d.mappingsFor(mapping.ARN)
+	if err != nil {
+		return err
+	}
+	if mappings.find(mapping.ARN) >= 0 {
+		return fmt.Errorf("%s is already mapped in the aws-auth ConfigMap", mapping.ARN)
+	}
+	mapping.ARN = AWSAuthRoleARN(mapping.ARN)
+	mappings.entries = append(mappings.entries, map[string]interface{}{})
+	mappings.set(len(mappings.entries)-1, &mapping)
+	d.managed[mapping.ARN] = true
+	d.managedChanged = true
+	return nil
+}
+
+// isManaged reports whether the connector created the mapping of the principal.
+func (d *awsAuthDocument) isManaged(principalArn string) bool {
+	return d.managed[AWSAuthRoleARN(principalArn)]
+}
+
+// update applies fn to the mapping of the principal. It returns false if the principal isn't mapped.
+// The entry is only rewritten if fn changes its username or groups.
+func (d *awsAuthDocument) update(principalArn string, fn func(mapping *AWSAuthMapping)) (bool, error) {
+	mappings, err := d.mappingsFor(principalArn)
+	if err != nil {
+		return false, err
+	}
+	i := mappings.find(principalArn)
+	if i < 0 {
+		return false, nil
+	}
+	current := mappings.mapping(i)
+	updated := *current
+	updated.Groups = slices.Clone(current.Groups)
+	fn(&updated)
+	if updated.Username != current.Username || !slices.Equal(updated.Groups, current.Groups) {
+		updated.ARN = current.ARN
+		mappings.set(i, &updated)
+	}
+	return true, nil
+}
+
+// remove deletes the mapping of the principal. It returns false if the principal isn't mapped.
+func (d *awsAuthDocument) remove(principalArn string) (bool, error) {
+	mappings, err := d.mappingsFor(principalArn)
+	if err != nil {
+		return false, err
+	}
+	i := mappings.find(principalArn)
+	if i < 0 {
+		return false, nil
+	}
+	mappings.entries = slices.Delete(mappings.entries, i, i+1)
+	mappings.changed = true
+	if d.isManaged(principalArn) {
+		delete(d.managed, AWSAuthRoleARN(principalArn))
+		d.managedChanged = true
+	}
+	return true, nil
+}
+
+// removeManaged deletes the mapping of the principal if the connector created it and it has no groups.
+// It returns false if the mapping was kept.
+func (d *awsAuthDocument) removeManaged(principalArn string) (bool, error) {
+	if !d.isManaged(principalArn) {
+		return false, nil
+	}
+	mapping, err := d.get(principalArn)
+	if err != nil || mapping == nil || len(mapping.Groups) > 0 {
+		return false, err
+	}
+	return d.remove(principalArn)
+}
+
+func (d *awsAuthDocument) changed() bool {
+	return d.users.changed || d.roles.changed || d.managedChanged
+}
+
+// writeAnnotations records the mappings the connector created in the annotations of the ConfigMap.
+func (d *awsAuthDocument) writeAnnotations(annotations map[string]string) error {
+	if !d.managedChanged {
+		return nil
+	}
+	if len(d.managed) == 0 {
+		delete(annotations, awsAuthManagedAnnotation)
+		return nil
+	}
+	managed := make([]string, 0, len(d.managed))
+	for arn := range d.managed {
+		managed = append(managed, arn)
+	}
+	slices.Sort(managed)
+	value, err := json.Marshal(managed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s annotation: %w", awsAuthManagedAnnotation, err)
+	}
+	annotations[awsAuthManagedAnnotation] = string(value)
+	return nil
+}
+
+// write marshals the lists that changed into data.
+func (d *awsAuthDocument) write(data map[string]string) error {
+	for _, mappings := range []*awsAuthMappings{d.users, d.roles} {
+		if !mappings.changed {
+			continue
+		}
+		if len(mappings.entries) == 0 {
+			data[mappings.key] = "[]\n"
+			continue
+		}
+		value, err := yaml.Marshal(mappings.entries)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", mappings.key, err)
+		}
+		data[mappings.key] = string(value)
+	}
+	return nil
+}
+
+// awsAuthConfigMapClient is the subset of the ConfigMap client the aws-auth editor uses.
+type awsAuthConfigMapClient interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error)
+	Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error)
+	Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error)
+}
+
+// awsAuthEditor reads and edits the aws-auth ConfigMap of a cluster.
+type awsAuthEditor struct {
+	configMaps awsAuthConfigMapClient
+}
+
+// read returns the aws-auth ConfigMap, or a new one if the cluster doesn't have it, and its parsed content.
+func (e *awsAuthEditor) read(ctx context.Context) (*corev1.ConfigMap, bool, *awsAuthDocument, error) {
+	exists := true
+	configMap, err := e.configMaps.Get(ctx, awsAuthConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		exists = false
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      awsAuthConfigMapName,
+				Namespace: awsAuthConfigMapNamespace,
+			},
+		}
+	} else if err != nil {
+		return nil, false, nil, fmt.Errorf("failed to get aws-auth ConfigMap: %w", err)
+	}
+
+	doc, err := parseAWSAuthDocument(configMap.Data, configMap.Annotations)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return configMap, exists, doc, nil
+}
+
+// edit applies fn to the content of the aws-auth ConfigMap, and writes it back if fn changed it.
+// The ConfigMap is created if the cluster doesn't have it. Updates are made against the resource version
+// that was read, and edits that conflict with a concurrent change are applied again to the latest version.
+func (e *awsAuthEditor) edit(ctx context.Context, fn func(doc *awsAuthDocument) error) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMap, exists, doc, err := e.read(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+		if !doc.changed() {
+			return nil
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		if err := doc.write(configMap.Data); err != nil {
+			return err
+		}
+		if configMap.Annotations == nil {
+			configMap.Annotations = make(map[string]string)
+		}
+		if err := doc.writeAnnotations(configMap.Annotations); err != nil {
+			return err
+		}
+
+		if !exists {
+			_, err = e.configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Someone else created the ConfigMap in the meantime: edit theirs instead.
+				return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, awsAuthConfigMapName, err)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create aws-auth ConfigMap: %w", err)
+			}
+			return nil
+		}
+
+		_, err = e.configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				return err
+			}
+			return fmt.Errorf("failed to update aws-auth ConfigMap: %w", err)
+		}
+		return nil
+	})
+}
+
+func (c *EKSClient) awsAuth() *awsAuthEditor {
+	return &awsAuthEditor{configMaps: c.kubernetes.CoreV1().ConfigMaps(awsAuthConfigMapNamespace)}
+}
+
+// GetAWSAuthMapping returns the aws-auth mapping of an IAM user or role, or nil if it isn't mapped.
+func (c *EKSClient) GetAWSAuthMapping(ctx context.Context, principalArn string) (*AWSAuthMapping, error) {
+	_, _, doc, err := c.awsAuth().read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return doc.get(principalArn)
+}
+
+// AddIAMUserMapping maps an IAM user to its ARN in the mapUsers data of the aws-auth ConfigMap.
+func (c *EKSClient) AddIAMUserMapping(ctx context.Context, userArn string) error {
+	return c.addAWSAuthMapping(ctx, AWSAuthMapping{ARN: userArn, Username: userArn})
+}
+
+// AddIAMRoleMapping maps an IAM role to its ARN, without the role path, in the mapRoles data of the aws-auth ConfigMap.
+func (c *EKSClient) AddIAMRoleMapping(ctx context.Context, roleArn string) error {
+	return c.addAWSAuthMapping(ctx, AWSAuthMapping{ARN: roleArn, Username: AWSAuthRoleARN(roleArn)})
+}
+
+func (c *EKSClient) addAWSAuthMapping(ctx context.Context, mapping AWSAuthMapping) error {
+	err := c.awsAuth().edit(ctx, func(doc *awsAuthDocument) error {
+		return doc.add(mapping)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add aws-auth mapping of %s: %w", mapping.ARN, err)
+	}
+	return nil
+}
+
+// UpdateAWSAuthMappingGroups updates the Kubernetes groups of the aws-auth mapping of an IAM user or role.
+// The ConfigMap is only updated if the groups change. It returns false if the principal isn't mapped in the aws-auth ConfigMap.
+func (c *EKSClient) UpdateAWSAuthMappingGroups(ctx context.Context, principalArn string, update func(groups []string) []string) (bool, error) {
+	var found bool
+	err := c.awsAuth().edit(ctx, func(doc *awsAuthDocument) error {
+		var err error
+		found, err = doc.update(principalArn, func(mapping *AWSAuthMapping) {
+			mapping.Groups = update(mapping.Groups)
+		})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update aws-auth mapping of %s: %w", principalArn, err)
+	}
+	return found, nil
+}
+
+// RemoveAWSAuthMapping removes the aws-auth mapping of an IAM user or role.
+// It returns false if the principal isn't mapped in the aws-auth ConfigMap.
+func (c *EKSClient) RemoveAWSAuthMapping(ctx context.Context, principalArn string) (bool, error) {
+	var removed bool
+	err := c.awsAuth().edit(ctx, func(doc *awsAuthDocument) error {
+		var err error
+		removed, err = doc.remove(principalArn)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to remove aws-auth mapping of %s: %w", principalArn, err)
+	}
+	return removed, nil
+}
+
+// RemoveManagedAWSAuthMapping removes the aws-auth mapping of an IAM user or role if the connector created it.
+// Mappings with Kubernetes groups are kept, as they still grant group membership.
+// It returns false if no mapping was removed.
+func (c *EKSClient) RemoveManagedAWSAuthMapping(ctx context.Context, principalArn string) (bool, error) {
+	var removed bool
+	err := c.awsAuth().edit(ctx, func(doc *awsAuthDocument) error {
+		var err error
+		removed, err = doc.removeManaged(principalArn)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to remove aws-auth mapping of %s: %w", principalArn, err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// fakeAWSAuthConfigMaps stores a single ConfigMap and enforces resource versions on update.
+type fakeAWSAuthConfigMaps struct {
+	configMap *corev1.ConfigMap
+	version   int
+	// concurrentEdit is applied to the stored ConfigMap before the next update, as if another client edited it.
+	concurrentEdit func(configMap *corev1.ConfigMap)
+	updates        int
+}
+
+var configMapsResource = schema.GroupResource{Resource: "configmaps"}
+
+func (f *fakeAWSAuthConfigMaps) store(configMap *corev1.ConfigMap) {
+	f.version++
+	f.configMap = configMap.DeepCopy()
+	f.configMap.ResourceVersion = strconv.Itoa(f.version)
+}
+
+func (f *fakeAWSAuthConfigMaps) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error) {
+	if f.configMap == nil {
+		return nil, apierrors.NewNotFound(configMapsResource, name)
+	}
+	return f.configMap.DeepCopy(), nil
+}
+
+func (f *fakeAWSAuthConfigMaps) Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	if f.configMap != nil {
+		return nil, apierrors.NewAlreadyExists(configMapsResource, configMap.Name)
+	}
+	f.store(configMap)
+	return f.configMap.DeepCopy(), nil
+}
+
+func (f *fakeAWSAuthConfigMaps) Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error) {
+	if f.concurrentEdit != nil {
+		edited := f.configMap.DeepCopy()
+		f.concurrentEdit(edited)
+		f.concurrentEdit = nil
+		f.store(edited)
+	}
+	if configMap.ResourceVersion != f.configMap.ResourceVersion {
+		return nil, apierrors.NewConflict(configMapsResource, configMap.Name, nil)
+	}
+	f.updates++
+	f.store(configMap)
+	return f.configMap.DeepCopy(), nil
+}
+
+func newFakeAWSAuth(data map[string]string) *fakeAWSAuthConfigMaps {
+	f := &fakeAWSAuthConfigMaps{}
+	f.store(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: awsAuthConfigMapName, Namespace: awsAuthConfigMapNamespace},
+		Data:       data,
+	})
+	return f
+}
+
+func TestAWSAuthRoleARN(t *testing.T) {
+	assert.Equal(t, "arn:aws:iam::123456789012:role/deployer", AWSAuthRoleARN("arn:aws:iam::123456789012:role/ci/teams/deployer"))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/deployer", AWSAuthRoleARN("arn:aws:iam::123456789012:role/deployer"))
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:role/deployer", AWSAuthRoleARN("arn:aws-us-gov:iam::123456789012:role/ci/deployer"))
+	assert.Equal(t, "arn:aws:iam::123456789012:user/ci/alice", AWSAuthRoleARN("arn:aws:iam::123456789012:user/ci/alice"))
+}
+
+func TestAWSAuthDocument_Update(t *testing.T) {
+	doc, err := parseAWSAuthDocument(map[string]string{
+		"mapUsers": "- userarn: arn:aws:iam::123456789012:user/alice\n  username: alice\n  groups:\n  - viewers\n",
+		"mapRoles": "- rolearn: arn:aws:iam::123456789012:role/deployer\n  username: deployer\n",
+	}, nil)
+	require.NoError(t, err)
+	addDevelopers := func(mapping *AWSAuthMapping) {
+		mapping.Groups = append(mapping.Groups, "developers")
+	}
+
+	found, err := doc.update("arn:aws:iam::123456789012:user/alice", addDevelopers)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, doc.users.changed)
+	assert.False(t, doc.roles.changed)
+
+	// aws-auth matches roles without their paths.
+	found, err = doc.update("arn:aws:iam::123456789012:role/ci/deployer", func(mapping *AWSAuthMapping) {})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.False(t, doc.roles.changed)
+
+	found, err = doc.update("arn:aws:iam::123456789012:role/deployer", addDevelopers)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, doc.roles.changed)
+
+	found, err = doc.update("arn:aws:iam::123456789012:user/bob", addDevelopers)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	alice, err := doc.get("arn:aws:iam::123456789012:user/alice")
+	require.NoError(t, err)
+	assert.Equal(t, &AWSAuthMapping{
+		ARN:      "arn:aws:iam::123456789012:user/alice",
+		Username: "alice",
+		Groups:   []string{"viewers", "developers"},
+	}, alice)
+
+	_, err = doc.get("arn:aws:sts::123456789012:assumed-role/deployer/session")
+	assert.Error(t, err)
+}
+
+func TestAWSAuthEditor_PreservesUnknownFields(t *testing.T) {
+	mapAccounts := "- \"123456789012\"\n"
+	configMaps := newFakeAWSAuth(map[string]string{
+		"mapRoles":    "- rolearn: arn:aws:iam::123456789012:role/node\n  username: system:node:{{EC2PrivateDNSName}}\n  groups:\n  - system:nodes\n  comment: managed by eksctl\n",
+		"mapAccounts": mapAccounts,
+	})
+	editor := &awsAuthEditor{configMaps: configMaps}
+
+	err := editor.edit(context.Background(), func(doc *awsAuthDocument) error {
+		return doc.add(AWSAuthMapping{ARN: "arn:aws:iam::123456789012:role/ci/deployer", Username: "deployer"})
+	})
+	require.NoError(t, err)
+
+	data := configMaps.configMap.Data
+	assert.Equal(t, mapAccounts, data["mapAccounts"])
+	assert.NotContains(t, data, "mapUsers")
+	assert.Contains(t, data["mapRoles"], "comment: managed by eksctl")
+	assert.Contains(t, data["mapRoles"], "rolearn: arn:aws:iam::123456789012:role/deployer")
+
+	// Unchanged documents aren't written.
+	err = editor.edit(context.Background(), func(doc *awsAuthDocument) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, configMaps.updates)
+}
+
+func TestAWSAuthEditor_CreatesConfigMap(t *testing.T) {
+	configMaps := &fakeAWSAuthConfigMaps{}
+	editor := &awsAuthEditor{configMaps: configMaps}
+
+	err := editor.edit(context.Background(), func(doc *awsAuthDocument) error {
+		return doc.add(AWSAuthMapping{ARN: "arn:aws:iam::123456789012:user/alice", Username: "alice"})
+	})
+	require.NoError(t, err)
+	require.NotNil(t, configMaps.configMap)
+	assert.Equal(t, awsAuthConfigMapNamespace, configMaps.configMap.Namespace)
+
+	_, _, doc, err := editor.read(context.Background())
+	require.NoError(t, err)
+	alice, err := doc.get("arn:aws:iam::123456789012:user/alice")
+	require.NoError(t, err)
+	require.NotNil(t, alice)
+	assert.Equal(t, "alice", alice.Username)
+}
+
+func TestAWSAuthEditor_RetriesConflicts(t *testing.T) {
+	configMaps := newFakeAWSAuth(map[string]string{
+		"mapUsers": "- userarn: arn:aws:iam::123456789012:user/alice\n  username: alice\n",
+	})
+	configMaps.concurrentEdit = func(configMap *corev1.ConfigMap) {
+		configMap.Data["mapUsers"] += "- userarn: arn:aws:iam::123456789012:user/bob\n  username: bob\n"
+	}
+	editor := &awsAuthEditor{configMaps: configMaps}
+
+	err := editor.edit(context.Background(), func(doc *awsAuthDocument) error {
+		_, err := doc.remove("arn:aws:iam::123456789012:user/alice")
+		return err
+	})
+	require.NoError(t, err)
+
+	// The edit is applied again on top of the concurrent one.
+	_, _, doc, err := editor.read(context.Background())
+	require.NoError(t, err)
+	alice, err := doc.get("arn:aws:iam::123456789012:user/alice")
+	require.NoError(t, err)
+	assert.Nil(t, alice)
+	bob, err := doc.get("arn:aws:iam::123456789012:user/bob")
+	require.NoError(t, err)
+	assert.NotNil(t, bob)
+}
+
+func TestAWSAuthDocument_Add(t *testing.T) {
+	doc, err := parseAWSAuthDocument(nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, doc.add(AWSAuthMapping{ARN: "arn:aws:iam::123456789012:role/ci/deployer", Username: "deployer"}))
+	assert.Error(t, doc.add(AWSAuthMapping{ARN: "arn:aws:iam::123456789012:role/other/deployer"}))
+
+	data := map[string]string{}
+	require.NoError(t, doc.write(data))
+	assert.Equal(t, "- rolearn: arn:aws:iam::123456789012:role/deployer\n  username: deployer\n", data["mapRoles"])
+	assert.NotContains(t, data, "mapUsers")
+}
+
+func TestAWSAuthEditor_RemovesManagedMappings(t *testing.T) {
+	configMaps := newFakeAWSAuth(map[string]string{
+		"mapUsers": "- userarn: arn:aws:iam::123456789012:user/alice\n  username: alice\n",
+	})
+	editor := &awsAuthEditor{configMaps: configMaps}
+
+	err := editor.edit(context.Background(), func(doc *awsAuthDocument) error {
+		if err := doc.add(AWSAuthMapping{ARN: "arn:aws:iam::123456789012:user/bob", Username: "bob"}); err != nil {
+			return err
+		}
+		return doc.add(AWSAuthMapping{ARN: "arn:aws:iam::123456789012:role/ci/deployer", Username: "deployer", Groups: []string{"deployers"}})
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["arn:aws:iam::123456789012:role/deployer","arn:aws:iam::123456789012:user/bob"]`,
+		configMaps.configMap.Annotations[awsAuthManagedAnnotation])
+
+	var removed []bool
+	err = editor.edit(context.Background(), func(doc *awsAuthDocument) error {
+		removed = nil
+		for _, arn := range []string{
+			// Mapped by someone else.
+			"arn:aws:iam::123456789012:user/alice",
+			"arn:aws:iam::123456789012:user/bob",
+			// Still a member of a group.
+			"arn:aws:iam::123456789012:role/ci/deployer",
+			// Not mapped.
+			"arn:aws:iam::123456789012:user/carol",
+		} {
+			ok, err := doc.removeManaged(arn)
+			if err != nil {
+				return err
+			}
+			removed = append(removed, ok)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, false, false}, removed)
+
+	_, _, doc, err := editor.read(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, configMaps.configMap.Data["mapUsers"], "user/bob")
+	assert.Contains(t, configMaps.configMap.Data["mapUsers"], "user/alice")
+	assert.False(t, doc.isManaged("arn:aws:iam::123456789012:user/bob"))
+	assert.True(t, doc.isManaged("arn:aws:iam::123456789012:role/deployer"))
+}
+
+// newAWSAuthTestClient returns a client whose Kubernetes API serves the aws-auth ConfigMap from configMaps.
+func newAWSAuthTestClient(t *testing.T, configMaps *fakeAWSAuthConfigMaps) *EKSClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			configMap *corev1.ConfigMap
+			err       error
+		)
+		switch r.Method {
+		case http.MethodGet:
+			configMap, err = configMaps.Get(r.Context(), awsAuthConfigMapName, metav1.GetOptions{})
+		case http.MethodPut:
+			configMap = &corev1.ConfigMap{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(configMap))
+			configMap, err = configMaps.Update(r.Context(), configMap, metav1.UpdateOptions{})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var v interface{} = configMap
+		if status, ok := err.(apierrors.APIStatus); ok {
+			w.WriteHeader(int(status.Status().Code))
+			v = status.Status()
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(v))
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewEKSClient(&rest.Config{
+		Host:          server.URL,
+		ContentConfig: rest.ContentConfig{ContentType: "application/json"},
+		QPS:           -1,
+	}, nil, nil, testClusterName, 0)
+	require.NoError(t, err)
+	return c
+}
+
+func TestEKSClient_RemoveAWSAuthMapping(t *testing.T) {
+	configMaps := newFakeAWSAuth(map[string]string{
+		"mapUsers": "- userarn: arn:aws:iam::123456789012:user/alice\n  username: alice\n" +
+			"- userarn: arn:aws:iam::123456789012:user/bob\n  username: bob\n  groups:\n  - developers\n",
+	})
+	c := newAWSAuthTestClient(t, configMaps)
+	ctx := context.Background()
+
+	// Mappings are removed whoever created them and whatever their groups.
+	for _, arn := range []string{"arn:aws:iam::123456789012:user/alice", "arn:aws:iam::123456789012:user/bob"} {
+		removed, err := c.RemoveAWSAuthMapping(ctx, arn)
+		require.NoError(t, err)
+		assert.True(t, removed, arn)
+	}
+	assert.NotContains(t, configMaps.configMap.Data["mapUsers"], "userarn")
+
+	removed, err := c.RemoveAWSAuthMapping(ctx, "arn:aws:iam::123456789012:user/carol")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
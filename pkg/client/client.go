@@ -2,9 +2,9 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/url"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -32,8 +32,21 @@ type EKSClient struct {
 	clusterName    string
 	cacheUsersMap  map[string][]string
 	cacheGroupsMap map[string][]string
-	identityMutex  sync.Mutex
-	idCacheExpiry  time.Time
+	// cacheUsernameTemplates match the usernames rendered from templated usernames, which cacheUsersMap leaves out.
+	cacheUsernameTemplates []*usernameTemplate
+	// cacheMappedAccounts are the accounts of aws-auth mapAccounts, and cacheMappedARNs the principals
+	// mapped explicitly, which take precedence over their account's mapping.
+	cacheMappedAccounts map[string]bool
+	cacheMappedARNs     map[string]bool
+	identityMutex       sync.Mutex
+	idCacheExpiry       time.Time
+
+	iamIndexMutex sync.Mutex
+	iamIndex      *iamIndex
+
+	accessEntryConcurrency int
+	accessEntriesMutex     sync.Mutex
+	accessEntries          *AccessEntrySnapshot
 }
 
 const (
@@ -55,16 +68,23 @@ func NewClient(cfg *rest.Config, awsCfg aws.Config, clusterName string) (*EKSCli
 	iamClient := iam.NewFromConfig(awsCfg)
 
 	return &EKSClient{
-		kubernetes:     client,
-		eksClient:      eksClient,
-		iamClient:      iamClient,
-		clusterName:    clusterName,
-		cacheUsersMap:  make(map[string][]string),
-		cacheGroupsMap: make(map[string][]string),
+		kubernetes:             client,
+		eksClient:              eksClient,
+		iamClient:              iamClient,
+		clusterName:            clusterName,
+		cacheUsersMap:          make(map[string][]string),
+		cacheGroupsMap:         make(map[string][]string),
+		accessEntryConcurrency: DefaultAccessEntryConcurrency,
 	}, nil
 }
 
-func NewEKSClient(cfg *rest.Config, iamClient *iam.Client, eksClient *eks.Client, clusterName string) (*EKSClient, error) {
+// NewEKSClient returns a client for the cluster. Access entries are described by up to accessEntryConcurrency
+// concurrent calls, or DefaultAccessEntryConcurrency if it isn't positive.
+func NewEKSClient(cfg *rest.Config, iamClient *iam.Client, eksClient *eks.Client, clusterName string, accessEntryConcurrency int) (*EKSClient, error) {
+	if accessEntryConcurrency <= 0 {
+		accessEntryConcurrency = DefaultAccessEntryConcurrency
+	}
+
 	// Create kubernetes client
 	client, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
@@ -72,13 +92,23 @@ func NewEKSClient(cfg *rest.Config, iamClient *iam.Client, eksClient *eks.Client
 	}
 
 	return &EKSClient{
-		kubernetes:     client,
-		eksClient:      eksClient,
+		kubernetes:             client,
+		eksClient:              eksClient,
+		iamClient:              iamClient,
+		clusterName:            clusterName,
+		cacheUsersMap:          make(map[string][]string),
+		cacheGroupsMap:         make(map[string][]string),
+		accessEntryConcurrency: accessEntryConcurrency,
+	}, nil
+}
+
+// NewIAMClient returns a client for the account-wide IAM APIs, not bound to any cluster.
+func NewIAMClient(iamClient *iam.Client) *EKSClient {
+	return &EKSClient{
 		iamClient:      iamClient,
-		clusterName:    clusterName,
 		cacheUsersMap:  make(map[string][]string),
 		cacheGroupsMap: make(map[string][]string),
-	}, nil
+	}
 }
 
 // Load or refresh the identity cache (user/group mappings from aws-auth and access entries).
@@ -101,7 +131,7 @@ func (c *EKSClient) LoadIdentityCacheMaps(ctx context.Context) error {
 
 	awsAuthAccessible := true
 	// Get aws-auth ConfigMap mappings
-	awsAuthUserMap, awsAuthGroupMap, err := c.getAwsAuthMappings(ctx)
+	awsAuthUserMap, awsAuthGroupMap, mappedAccounts, err := c.getAwsAuthMappings(ctx)
 	if err != nil {
 		// Log the error but continue with access entries
 		// aws-auth might not exist or be accessible
@@ -121,7 +151,8 @@ func (c *EKSClient) LoadIdentityCacheMaps(ctx context.Context) error {
 			zap.Int("user_mappings", len(awsAuthUserMap)),
 			zap.Int("total_user_arns", totalUsers),
 			zap.Int("group_mappings", len(awsAuthGroupMap)),
-			zap.Int("total_group_arns", totalGroups))
+			zap.Int("total_group_arns", totalGroups),
+			zap.Int("mapped_accounts", len(mappedAccounts)))
 	}
 
 	// Merge aws-auth mappings into the result maps
@@ -181,18 +212,96 @@ func (c *EKSClient) LoadIdentityCacheMaps(ctx context.Context) error {
 		zap.Int("total_group_mappings", len(groupMap)),
 		zap.Int("total_group_arns", totalFinalGroups))
 
+	mappedARNs := make(map[string]bool)
+	for _, mappings := range []map[string][]string{userMap, groupMap} {
+		for _, arns := range mappings {
+			for _, arn := range arns {
+				mappedARNs[AWSAuthRoleARN(arn)] = true
+			}
+		}
+	}
+
+	// Templated usernames never appear as such in bindings, so they're matched by pattern instead.
+	var templates []*usernameTemplate
+	for username, arns := range userMap {
+		if !IsUsernameTemplate(username) {
+			continue
+		}
+		delete(userMap, username)
+		for _, arn := range arns {
+			template, err := compileUsernameTemplate(arn, username)
+			if err != nil {
+				l.Debug("skipping templated username", zap.String("arn", arn), zap.Error(err))
+				continue
+			}
+			templates = append(templates, template)
+		}
+	}
+
 	c.cacheUsersMap = userMap
 	c.cacheGroupsMap = groupMap
+	c.cacheUsernameTemplates = templates
+	c.cacheMappedAccounts = make(map[string]bool, len(mappedAccounts))
+	for _, account := range mappedAccounts {
+		c.cacheMappedAccounts[account] = true
+	}
+	c.cacheMappedARNs = mappedARNs
 	c.idCacheExpiry = now.Add(cacheTTL)
 	return nil
 }
 
-// Lookup AWS ARNs by Kubernetes username.
-func (c *EKSClient) LookupArnsByUsername(ctx context.Context, username string) ([]string, error) {
+// LookupArnsByUsername returns the IAM principals mapped to a Kubernetes username, including the roles
+// whose templated username, such as sso:{{SessionName}}, renders to it for some session, and the IAM users
+// and roles of aws-auth mapAccounts, whose username is their ARN.
+func (c *EKSClient) LookupArnsByUsername(ctx context.Context, username string) ([]UsernameMatch, error) {
+	if err := c.LoadIdentityCacheMaps(ctx); err != nil {
+		return nil, err
+	}
+	c.identityMutex.Lock()
+	defer c.identityMutex.Unlock()
+
+	var matches []UsernameMatch
+	for _, arn := range c.cacheUsersMap[username] {
+		matches = append(matches, UsernameMatch{ARN: arn})
+	}
+	for _, template := range c.cacheUsernameTemplates {
+		if match, ok := template.match(username); ok {
+			matches = append(matches, match)
+		}
+	}
+	if c.isMappedByAccount(username) {
+		matches = append(matches, UsernameMatch{ARN: username})
+	}
+	return matches, nil
+}
+
+// isMappedByAccount reports whether the username is the ARN of an IAM user or role that aws-auth maps
+// through mapAccounts. aws-auth maps roles of mapped accounts to their ARN without its path.
+func (c *EKSClient) isMappedByAccount(username string) bool {
+	principal, err := ParseIAMPrincipalARN(username)
+	if err != nil || principal.Type == "root" || !c.cacheMappedAccounts[principal.AccountID] {
+		return false
+	}
+	if principal.Type == "role" && principal.Path != "/" {
+		return false
+	}
+	return !c.cacheMappedARNs[AWSAuthRoleARN(username)]
+}
+
+// ListMappedAccounts lists the AWS accounts whose IAM users and roles are all mapped by aws-auth mapAccounts.
+func (c *EKSClient) ListMappedAccounts(ctx context.Context) ([]string, error) {
 	if err := c.LoadIdentityCacheMaps(ctx); err != nil {
 		return nil, err
 	}
-	return c.cacheUsersMap[username], nil
+	c.identityMutex.Lock()
+	defer c.identityMutex.Unlock()
+
+	accounts := make([]string, 0, len(c.cacheMappedAccounts))
+	for account := range c.cacheMappedAccounts {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts, nil
 }
 
 // Lookup AWS ARNs by Kubernetes group.
@@ -200,14 +309,48 @@ func (c *EKSClient) LookupArnsByGroup(ctx context.Context, group string) ([]stri
 	if err := c.LoadIdentityCacheMaps(ctx); err != nil {
 		return nil, err
 	}
-	return c.cacheGroupsMap[group], nil
+	c.identityMutex.Lock()
+	defer c.identityMutex.Unlock()
+
+	// Callers get their own copy, since the cache is reloaded in place.
+	return slices.Clone(c.cacheGroupsMap[group]), nil
+}
+
+// ListMappedPrincipals lists the ARNs of the IAM principals mapped by aws-auth or access entries.
+func (c *EKSClient) ListMappedPrincipals(ctx context.Context) ([]string, error) {
+	if err := c.LoadIdentityCacheMaps(ctx); err != nil {
+		return nil, err
+	}
+	c.identityMutex.Lock()
+	defer c.identityMutex.Unlock()
+
+	seen := make(map[string]bool)
+	var principals []string
+	for _, mappings := range []map[string][]string{c.cacheUsersMap, c.cacheGroupsMap} {
+		for _, arns := range mappings {
+			for _, arn := range arns {
+				if !seen[arn] {
+					seen[arn] = true
+					principals = append(principals, arn)
+				}
+			}
+		}
+	}
+	for _, template := range c.cacheUsernameTemplates {
+		if !seen[template.arn] {
+			seen[template.arn] = true
+			principals = append(principals, template.arn)
+		}
+	}
+	sort.Strings(principals)
+	return principals, nil
 }
 
 // Fetch and parse aws-auth ConfigMap.
-func (c *EKSClient) getAwsAuthMappings(ctx context.Context) (map[string][]string, map[string][]string, error) {
+func (c *EKSClient) getAwsAuthMappings(ctx context.Context) (map[string][]string, map[string][]string, []string, error) {
 	cm, err := c.kubernetes.CoreV1().ConfigMaps("kube-system").Get(ctx, "aws-auth", metav1.GetOptions{})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get aws-auth configmap: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get aws-auth configmap: %w", err)
 	}
 
 	userMap := make(map[string][]string)  // k8s username -> AWS user ARN list
@@ -251,151 +394,308 @@ func (c *EKSClient) getAwsAuthMappings(ctx context.Context) (map[string][]string
 			}
 		}
 	}
-	return userMap, groupMap, nil
+	// Parse mapAccounts
+	var accounts []string
+	if accountsYaml, ok := cm.Data["mapAccounts"]; ok && strings.TrimSpace(accountsYaml) != "" {
+		accounts, err = parseMapAccounts(accountsYaml)
+		if err != nil {
+			// The user and role mappings are still usable, so only the mapped accounts are dropped.
+			ctxzap.Extract(ctx).Warn("failed to parse aws-auth mapAccounts, ignoring mapped accounts", zap.Error(err))
+		}
+	}
+	return userMap, groupMap, accounts, nil
+}
+
+// parseMapAccounts parses the account IDs of aws-auth mapAccounts.
+func parseMapAccounts(accountsYaml string) ([]string, error) {
+	var mapAccounts []interface{}
+	if err := yaml.Unmarshal([]byte(accountsYaml), &mapAccounts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mapAccounts: %w", err)
+	}
+	accounts := make([]string, 0, len(mapAccounts))
+	for _, entry := range mapAccounts {
+		account, err := mapAccountID(entry)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
 }
 
 // ListIAMRoles lists IAM roles with pagination support.
+// Roles are read from the IAM index, which is refreshed when the first page is listed.
 func (c *EKSClient) ListIAMRoles(ctx context.Context, nextToken *string) ([]*IAMRole, *string, error) {
-	l := ctxzap.Extract(ctx)
-	var roles []*IAMRole
-
-	input := &iam.ListRolesInput{
-		MaxItems: aws.Int32(100),
-	}
-	if nextToken != nil && *nextToken != "" {
-		input.Marker = nextToken
-	}
-	page, err := c.iamClient.ListRoles(ctx, input)
+	pageToken := aws.ToString(nextToken)
+	index, err := c.loadIAMIndex(ctx, pageToken == "")
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list IAM roles: %w", err)
 	}
-
-	if page != nil {
-		for _, role := range page.Roles {
-			if role.RoleName == nil ||
-				role.RoleId == nil ||
-				role.Arn == nil {
-				l.Warn("missing information, skipping IAM role", zap.String("role_name", *role.RoleName))
-				continue
-			}
-			roles = append(roles, &IAMRole{
-				RoleName:   *role.RoleName,
-				RoleID:     *role.RoleId,
-				ARN:        *role.Arn,
-				CreateDate: role.CreateDate,
-				Path:       role.Path,
-			})
-		}
-		return roles, page.Marker, nil
-	}
-
-	return roles, nil, nil
+	return index.rolesPage(pageToken)
 }
 
-// GetIAMRole gets details for a specific IAM role.
-func (c *EKSClient) GetIAMRole(ctx context.Context, roleName string) (*IAMRole, error) {
-	result, err := c.iamClient.GetRole(ctx, &iam.GetRoleInput{
-		RoleName: aws.String(roleName),
-	})
+// TrustPolicy represents the structure of an IAM role trust policy.
+type TrustPolicy = PolicyDocument
+
+// ListRoleTrustPrincipals lists the AWS principals that can assume any of the roles in the IAM index.
+func (c *EKSClient) ListRoleTrustPrincipals(ctx context.Context) ([]string, error) {
+	trusts, err := c.ListRoleTrusts(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get IAM role %s: %w", roleName, err)
+		return nil, err
 	}
 
-	role := result.Role
-	if role.RoleName == nil ||
-		role.RoleId == nil ||
-		role.Arn == nil {
-		return nil, fmt.Errorf("missing information, skipping IAM role %s", *role.RoleName)
+	var principals []string
+	for _, trust := range trusts {
+		for _, principal := range trust.Principals {
+			principals = append(principals, principal.ARN)
+		}
 	}
-	return &IAMRole{
-		RoleName:   *role.RoleName,
-		RoleID:     *role.RoleId,
-		ARN:        *role.Arn,
-		CreateDate: role.CreateDate,
-		Path:       role.Path,
-	}, nil
+	return principals, nil
 }
 
-// GetIAMRoleTrustPolicy gets the trust policy for a specific IAM role.
-func (c *EKSClient) GetIAMRoleTrustPolicy(ctx context.Context, roleName string) (string, error) {
-	result, err := c.iamClient.GetRole(ctx, &iam.GetRoleInput{
-		RoleName: aws.String(roleName),
-	})
+// ListRoleTrusts returns the evaluated trust policies of the roles in the IAM index, by role ARN.
+func (c *EKSClient) ListRoleTrusts(ctx context.Context) (map[string]*RoleTrust, error) {
+	index, err := c.loadIAMIndex(ctx, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to get IAM role %s: %w", roleName, err)
+		return nil, err
 	}
 
-	if result.Role.AssumeRolePolicyDocument == nil {
-		return "", fmt.Errorf("no trust policy found for role %s", roleName)
+	trusts := make(map[string]*RoleTrust, len(index.roles))
+	for _, role := range index.roles {
+		trust, _ := index.roleTrust(c, role.RoleName)
+		trusts[role.ARN] = trust
 	}
+	return trusts, nil
+}
 
-	return *result.Role.AssumeRolePolicyDocument, nil
+// RoleTrust is the set of identities the trust policy of an IAM role lets assume it.
+type RoleTrust struct {
+	// Principals are the AWS principals allowed to call sts:AssumeRole.
+	Principals []TrustedPrincipal
+	// WebIdentities are the OIDC identities allowed to call sts:AssumeRoleWithWebIdentity.
+	WebIdentities []WebIdentityTrust
 }
 
-// TrustPolicy represents the structure of an IAM role trust policy.
-type TrustPolicy struct {
-	Version   string      `json:"Version"`
-	Statement []Statement `json:"Statement"`
+// TrustedPrincipal is an AWS principal, such as an IAM user or role, that can assume a role.
+type TrustedPrincipal struct {
+	// ARN is the ARN of the principal. Account principals are given as the root ARN of the account.
+	ARN string
+	// Via is the account root the principal was trusted through, when the trust policy trusts its account
+	// and its identity policies allow it to assume the role.
+	Via string
+	// SessionTags reports whether the principal can pass session tags (sts:TagSession).
+	SessionTags bool
+	// Conditions must hold for the principal to assume the role.
+	Conditions []PolicyCondition
+	// DenyConditions prevent the principal from assuming the role when they hold.
+	DenyConditions []PolicyCondition
 }
 
-// Statement represents a statement in the trust policy.
-type Statement struct {
-	Effect    string                 `json:"Effect"`
-	Principal map[string]interface{} `json:"Principal"`
-	Action    interface{}            `json:"Action"`
-	Condition map[string]interface{} `json:"Condition,omitempty"`
+// WebIdentityTrust lets the identities of an OIDC provider, such as the service accounts of an EKS cluster, assume a role.
+type WebIdentityTrust struct {
+	// Issuer is the OIDC issuer without its scheme, e.g. oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE.
+	Issuer string
+	// Subjects are the exact subjects allowed by StringEquals conditions.
+	Subjects []string
+	// SubjectPatterns are the subjects allowed by StringLike conditions, using IAM wildcards.
+	// A trust without a subject condition allows every subject, as the "*" pattern.
+	SubjectPatterns []string
+	// Conditions are the conditions of the trust other than the subject, such as the audience.
+	Conditions []PolicyCondition
+	// DenyConditions prevent the identities from assuming the role when they hold.
+	DenyConditions []PolicyCondition
 }
 
-// GetIAMRoleTrustPrincipals gets the principals that can assume a specific IAM role.
-func (c *EKSClient) GetIAMRoleTrustPrincipals(ctx context.Context, roleName string) ([]string, error) {
-	trustPolicyJSON, err := c.GetIAMRoleTrustPolicy(ctx, roleName)
+// GetIAMRoleTrust gets the identities that can assume a specific IAM role.
+// When the trust policy trusts an account, the IAM users of the account whose identity policies
+// allow them to assume the role are returned in its place.
+func (c *EKSClient) GetIAMRoleTrust(ctx context.Context, roleName string) (*RoleTrust, error) {
+	index, err := c.loadIAMIndex(ctx, false)
 	if err != nil {
 		return nil, err
 	}
+	if trust, ok := index.roleTrust(c, roleName); ok {
+		return trust, nil
+	}
 
-	// URL decode the trust policy document as AWS IAM API returns URL-encoded JSON
-	decodedPolicy, err := url.QueryUnescape(trustPolicyJSON)
+	// Roles created since the index was fetched are read on their own.
+	result, err := c.iamClient.GetRole(ctx, &iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to URL decode trust policy for role %s: %w", roleName, err)
+		return nil, fmt.Errorf("failed to get IAM role %s: %w", roleName, err)
+	}
+	if result.Role.AssumeRolePolicyDocument == nil {
+		return nil, fmt.Errorf("no trust policy found for role %s", roleName)
 	}
 
-	var trustPolicy TrustPolicy
-	if err := json.Unmarshal([]byte(decodedPolicy), &trustPolicy); err != nil {
+	trustPolicy, err := parsePolicyDocument(*result.Role.AssumeRolePolicyDocument)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse trust policy for role %s: %w", roleName, err)
 	}
 
-	var principals []string
+	return c.roleTrust(trustPolicy, aws.ToString(result.Role.Arn), index.users), nil
+}
+
+// roleTrust evaluates the trust policy of a role. Account principals are expanded to the given users
+// of the account whose identity policies allow them to assume the role. Principals denied without
+// conditions are dropped, and the conditions of Allow and Deny statements are kept with each principal.
+func (c *EKSClient) roleTrust(trustPolicy *TrustPolicy, roleARN string, users []*userIdentityPolicies) *RoleTrust {
+	trust := &RoleTrust{}
+	var principals []*TrustedPrincipal
+	byARN := make(map[string]*TrustedPrincipal)
+
+	allow := func(principalARN string, conditions []PolicyCondition) *TrustedPrincipal {
+		trusted, ok := byARN[principalARN]
+		if !ok {
+			trusted = &TrustedPrincipal{ARN: principalARN, Conditions: conditions}
+			byARN[principalARN] = trusted
+			principals = append(principals, trusted)
+			return trusted
+		}
+		// Any statement that allows the principal unconditionally makes the trust unconditional.
+		if len(conditions) == 0 || len(trusted.Conditions) == 0 {
+			trusted.Conditions = nil
+		} else {
+			trusted.Conditions = appendConditions(trusted.Conditions, conditions...)
+		}
+		return trusted
+	}
+
+	sessionTags := make(map[string]bool)
 	for _, statement := range trustPolicy.Statement {
-		if statement.Effect == "Allow" {
-			// Check if the action allows assuming the role
-			actions := c.extractActions(statement.Action)
-			for _, action := range actions {
-				if action == "sts:AssumeRole" {
-					// Extract principals from the statement
-					statementPrincipals := extractPrincipals(statement.Principal)
-					principals = append(principals, statementPrincipals...)
+		if statement.Effect != "Allow" {
+			continue
+		}
+		if statement.matchesAction(actionAssumeRoleWithWebIdentity) {
+			trust.WebIdentities = append(trust.WebIdentities, extractWebIdentityTrusts(statement)...)
+		}
+		assumeRole := statement.matchesAction(actionAssumeRole)
+		tagSession := statement.matchesAction(actionTagSession)
+		conditions := parseConditions(statement.Condition)
+		for _, principal := range extractPrincipals(statement.Principal) {
+			principalARN := normalizeAWSPrincipal(principal, PartitionOf(roleARN))
+			if assumeRole {
+				allow(principalARN, conditions)
+			}
+			if tagSession {
+				sessionTags[principalARN] = true
+			}
+		}
+	}
+
+	// Accounts stand for the identities of the account that their identity policies allow to assume the role.
+	expandedAccounts := make(map[string]bool)
+	for _, account := range slices.Clone(principals) {
+		accountID, ok := AccountRootID(account.ARN)
+		if !ok {
+			continue
+		}
+		for _, user := range users {
+			if principalAccountID(user.ARN) != accountID {
+				continue
+			}
+			expandedAccounts[account.ARN] = true
+			allowed, conditions, denyConditions := evaluateIdentityPolicies(user.Policies, actionAssumeRole, roleARN)
+			if !allowed {
+				continue
+			}
+			_, direct := byARN[user.ARN]
+			trusted := allow(user.ARN, appendConditions(slices.Clone(account.Conditions), conditions...))
+			trusted.DenyConditions = appendConditions(trusted.DenyConditions, denyConditions...)
+			if !direct {
+				trusted.Via = account.ARN
+				sessionTags[user.ARN] = sessionTags[user.ARN] || sessionTags[account.ARN]
+			}
+		}
+	}
+
+	denied := make(map[string]bool)
+	for _, statement := range trustPolicy.Statement {
+		if statement.Effect != "Deny" {
+			continue
+		}
+		conditions := parseConditions(statement.Condition)
+		for _, trusted := range principals {
+			if !statement.matchesPrincipal(trusted.ARN) {
+				continue
+			}
+			if statement.matchesAction(actionAssumeRole) {
+				if len(conditions) == 0 {
+					denied[trusted.ARN] = true
+				} else {
+					trusted.DenyConditions = appendConditions(trusted.DenyConditions, conditions...)
+				}
+			}
+			if statement.matchesAction(actionTagSession) && len(conditions) == 0 {
+				sessionTags[trusted.ARN] = false
+			}
+		}
+		if statement.matchesAction(actionAssumeRoleWithWebIdentity) {
+			trust.WebIdentities = slices.DeleteFunc(trust.WebIdentities, func(webIdentity WebIdentityTrust) bool {
+				return statement.matchesWebIdentity(webIdentity.Issuer) && len(conditions) == 0
+			})
+			for i := range trust.WebIdentities {
+				if statement.matchesWebIdentity(trust.WebIdentities[i].Issuer) {
+					trust.WebIdentities[i].DenyConditions = appendConditions(trust.WebIdentities[i].DenyConditions, conditions...)
 				}
 			}
 		}
 	}
 
-	return principals, nil
+	for _, trusted := range principals {
+		if denied[trusted.ARN] || expandedAccounts[trusted.ARN] {
+			continue
+		}
+		trusted.SessionTags = sessionTags[trusted.ARN]
+		trust.Principals = append(trust.Principals, *trusted)
+	}
+	return trust
 }
 
-// extractActions extracts actions from the Action field which can be a string or array.
-func (c *EKSClient) extractActions(action interface{}) []string {
-	var actions []string
-	switch v := action.(type) {
-	case string:
-		actions = append(actions, v)
-	case []interface{}:
-		for _, a := range v {
-			if actionStr, ok := a.(string); ok {
-				actions = append(actions, actionStr)
+// matchesWebIdentity reports whether the statement applies to the identities of the OIDC issuer.
+func (s Statement) matchesWebIdentity(issuer string) bool {
+	for _, principal := range extractPrincipals(s.Principal) {
+		if principal == "*" {
+			return true
+		}
+	}
+	for _, provider := range extractPrincipalValues(s.Principal["Federated"]) {
+		if strings.HasSuffix(provider, ":oidc-provider/"+issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractWebIdentityTrusts extracts the OIDC provider trusts of a statement, with their subject conditions.
+func extractWebIdentityTrusts(statement Statement) []WebIdentityTrust {
+	var trusts []WebIdentityTrust
+	for _, provider := range extractPrincipalValues(statement.Principal["Federated"]) {
+		_, issuer, ok := strings.Cut(provider, ":oidc-provider/")
+		if !ok {
+			continue
+		}
+		trust := WebIdentityTrust{Issuer: issuer}
+		subjectKey := issuer + ":sub"
+		if equals, ok := statement.Condition["StringEquals"].(map[string]interface{}); ok {
+			trust.Subjects = extractPrincipalValues(equals[subjectKey])
+		}
+		if like, ok := statement.Condition["StringLike"].(map[string]interface{}); ok {
+			trust.SubjectPatterns = extractPrincipalValues(like[subjectKey])
+		}
+		if len(trust.Subjects) == 0 && len(trust.SubjectPatterns) == 0 {
+			trust.SubjectPatterns = []string{"*"}
+		}
+		for _, condition := range parseConditions(statement.Condition) {
+			if condition.Key == subjectKey && (condition.Operator == "StringEquals" || condition.Operator == "StringLike") {
+				continue
 			}
+			trust.Conditions = append(trust.Conditions, condition)
 		}
+		trusts = append(trusts, trust)
 	}
-	return actions
+	return trusts
 }
 
 // extractPrincipals extracts principal ARNs from the Principal field.
@@ -410,7 +710,7 @@ func extractPrincipals(principal map[string]interface{}) []string {
 	return principals
 }
 
-// extractPrincipalValues extracts values from principal fields which can be string or array.
+// extractPrincipalValues extracts values from policy fields, such as principals and resources, which can be string or array.
 func extractPrincipalValues(principal interface{}) []string {
 	var values []string
 	switch v := principal.(type) {
@@ -426,67 +726,20 @@ func extractPrincipalValues(principal interface{}) []string {
 	return values
 }
 
-// GetMapUserFromAWSAuthConfigMap gets the aws-auth configmap for IAM users.
-func (c *EKSClient) GetMapUserFromAWSAuthConfigMap(ctx context.Context, userArn string) (*mapUser, error) {
-	l := ctxzap.Extract(ctx)
-	configMap, err := c.kubernetes.CoreV1().ConfigMaps(awsAuthConfigMapNamespace).Get(ctx, awsAuthConfigMapName, metav1.GetOptions{})
-	if err != nil {
-		l.Error("failed to get aws-auth ConfigMap", zap.Error(err))
-		return nil, fmt.Errorf("failed to get aws-auth ConfigMap: %w", err)
+// ListKubernetesGroups lists the Kubernetes groups IAM principals are mapped to by aws-auth and access entries.
+func (c *EKSClient) ListKubernetesGroups(ctx context.Context) ([]string, error) {
+	if err := c.LoadIdentityCacheMaps(ctx); err != nil {
+		return nil, err
 	}
+	c.identityMutex.Lock()
+	defer c.identityMutex.Unlock()
 
-	if usersYaml, ok := configMap.Data["mapUsers"]; ok && strings.TrimSpace(usersYaml) != "" {
-		var iamUsers []mapUser
-		if err := yaml.Unmarshal([]byte(usersYaml), &iamUsers); err == nil {
-			for _, iamUser := range iamUsers {
-				if iamUser.UserARN != "" && iamUser.UserARN == userArn {
-					return &iamUser, nil
-				}
-			}
-		}
+	groups := make([]string, 0, len(c.cacheGroupsMap))
+	for group := range c.cacheGroupsMap {
+		groups = append(groups, group)
 	}
-	return nil, nil
-}
-
-func (c *EKSClient) AddIAMUserMapping(ctx context.Context, userArn string) error {
-	l := ctxzap.Extract(ctx)
-	configMap, err := c.kubernetes.CoreV1().ConfigMaps(awsAuthConfigMapNamespace).Get(ctx, awsAuthConfigMapName, metav1.GetOptions{})
-	if err != nil {
-		l.Error("failed to get aws-auth ConfigMap", zap.Error(err))
-		return fmt.Errorf("failed to get aws-auth ConfigMap: %w", err)
-	}
-
-	if usersYaml, ok := configMap.Data["mapUsers"]; ok && strings.TrimSpace(usersYaml) != "" {
-		var iamUsers []mapUser
-		if err := yaml.Unmarshal([]byte(usersYaml), &iamUsers); err == nil {
-			// Check if the user already exists
-			for _, iamUser := range iamUsers {
-				if iamUser.UserARN == userArn {
-					return fmt.Errorf("user %s already exists", userArn)
-				}
-			}
-			// If the user does not exist, add it
-			iamUsers = append(iamUsers, mapUser{
-				UserARN:  userArn,
-				Username: userArn,
-				Groups:   []string{},
-			})
-			updated, err := yaml.Marshal(iamUsers)
-			if err != nil {
-				l.Error("failed to marshal updated mapUsers YAML", zap.Error(err))
-				return fmt.Errorf("failed to marshal updated mapUsers YAML: %w", err)
-			}
-			configMap.Data["mapUsers"] = string(updated)
-			_, err = c.kubernetes.CoreV1().ConfigMaps(awsAuthConfigMapNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
-			if err != nil {
-				l.Error("failed to update aws-auth ConfigMap", zap.Error(err))
-				return fmt.Errorf("failed to update aws-auth ConfigMap: %w", err)
-			}
-			return nil
-		}
-	}
-
-	return fmt.Errorf("failed to add IAM user mapping, error unmarshalling mapUsers")
+	sort.Strings(groups)
+	return groups, nil
 }
 
 func (c *EKSClient) CreateClusterRoleBinding(ctx context.Context, bindingName string, clusterRoleName string, subjects []rbacv1.Subject) error {
@@ -578,6 +831,53 @@ func (c *EKSClient) DeleteRoleBinding(ctx context.Context, namespace, bindingNam
 	return nil
 }
 
+// IsUsernameBound reports whether a RoleBinding in any namespace or a ClusterRoleBinding has the Kubernetes user as a subject.
+func (c *EKSClient) IsUsernameBound(ctx context.Context, username string) (bool, error) {
+	opts := metav1.ListOptions{}
+	for {
+		bindings, err := c.kubernetes.RbacV1().ClusterRoleBindings().List(ctx, opts)
+		if err != nil {
+			return false, fmt.Errorf("failed to list ClusterRoleBindings: %w", err)
+		}
+		for _, binding := range bindings.Items {
+			if subjectsHaveUser(binding.Subjects, username) {
+				return true, nil
+			}
+		}
+		if bindings.Continue == "" {
+			break
+		}
+		opts.Continue = bindings.Continue
+	}
+
+	opts = metav1.ListOptions{}
+	for {
+		bindings, err := c.kubernetes.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return false, fmt.Errorf("failed to list RoleBindings: %w", err)
+		}
+		for _, binding := range bindings.Items {
+			if subjectsHaveUser(binding.Subjects, username) {
+				return true, nil
+			}
+		}
+		if bindings.Continue == "" {
+			break
+		}
+		opts.Continue = bindings.Continue
+	}
+	return false, nil
+}
+
+func subjectsHaveUser(subjects []rbacv1.Subject, username string) bool {
+	for _, subject := range subjects {
+		if subject.Kind == rbacv1.UserKind && subject.Name == username {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *EKSClient) ListNamespaces(ctx context.Context, opts metav1.ListOptions) (*corev1.NamespaceList, error) {
 	namespaces, err := c.kubernetes.CoreV1().Namespaces().List(ctx, opts)
 	if err != nil {
@@ -1,17 +1,23 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
 
 const testClusterName = "test-cluster"
-const actionStsAssumeRole = "sts:AssumeRole"
 
 func TestNewClient(t *testing.T) {
 	// Test creating a new EKS client
@@ -80,24 +86,6 @@ func TestEKSClient_MergingFunctionality(t *testing.T) {
 	assert.NotNil(t, &client.identityMutex)
 }
 
-func TestExtractActions(t *testing.T) {
-	c := &EKSClient{}
-
-	// Test string action
-	action := "sts:AssumeRole"
-	actions := c.extractActions(action)
-	if len(actions) != 1 || actions[0] != actionStsAssumeRole {
-		t.Errorf("Expected [sts:AssumeRole], got %v", actions)
-	}
-
-	// Test array of actions
-	actionArray := []interface{}{actionStsAssumeRole, "sts:GetCallerIdentity"}
-	actions = c.extractActions(actionArray)
-	if len(actions) != 2 {
-		t.Errorf("Expected 2 actions, got %d", len(actions))
-	}
-}
-
 func TestExtractPrincipalValues(t *testing.T) {
 	// Test string principal
 	principal := "arn:aws:iam::123456789012:user/testuser"
@@ -170,3 +158,229 @@ func TestURLDecodeTrustPolicy(t *testing.T) {
 		t.Errorf("Expected Effect 'Allow', got %s", statement.Effect)
 	}
 }
+
+func TestRoleTrust_WebIdentities(t *testing.T) {
+	policyJSON := `{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Principal": {"Federated": "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"},
+				"Action": "sts:AssumeRoleWithWebIdentity",
+				"Condition": {
+					"StringEquals": {
+						"oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE:sub": "system:serviceaccount:default:builder",
+						"oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE:aud": "sts.amazonaws.com"
+					}
+				}
+			},
+			{
+				"Effect": "Allow",
+				"Principal": {"Federated": "arn:aws:iam::123456789012:oidc-provider/oidc.eks.eu-west-1.amazonaws.com/id/OTHER"},
+				"Action": "sts:AssumeRoleWithWebIdentity",
+				"Condition": {
+					"StringLike": {
+						"oidc.eks.eu-west-1.amazonaws.com/id/OTHER:sub": ["system:serviceaccount:ci:*"]
+					}
+				}
+			},
+			{
+				"Effect": "Allow",
+				"Principal": {"AWS": "arn:aws:iam::123456789012:user/alice"},
+				"Action": "sts:AssumeRole"
+			}
+		]
+	}`
+
+	var trustPolicy TrustPolicy
+	require.NoError(t, json.Unmarshal([]byte(policyJSON), &trustPolicy))
+
+	trust := (&EKSClient{}).roleTrust(&trustPolicy, "arn:aws:iam::123456789012:role/test-role", nil)
+	assert.Equal(t, []TrustedPrincipal{{ARN: "arn:aws:iam::123456789012:user/alice"}}, trust.Principals)
+	require.Len(t, trust.WebIdentities, 2)
+
+	assert.Equal(t, "oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE", trust.WebIdentities[0].Issuer)
+	assert.Equal(t, []string{"system:serviceaccount:default:builder"}, trust.WebIdentities[0].Subjects)
+	assert.Empty(t, trust.WebIdentities[0].SubjectPatterns)
+	assert.Equal(t, []PolicyCondition{
+		{Operator: "StringEquals", Key: "oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE:aud", Values: []string{"sts.amazonaws.com"}},
+	}, trust.WebIdentities[0].Conditions)
+
+	assert.Equal(t, "oidc.eks.eu-west-1.amazonaws.com/id/OTHER", trust.WebIdentities[1].Issuer)
+	assert.Empty(t, trust.WebIdentities[1].Subjects)
+	assert.Equal(t, []string{"system:serviceaccount:ci:*"}, trust.WebIdentities[1].SubjectPatterns)
+}
+
+func parseTestPolicy(t *testing.T, policyJSON string) *PolicyDocument {
+	t.Helper()
+	var policy PolicyDocument
+	require.NoError(t, json.Unmarshal([]byte(policyJSON), &policy))
+	return &policy
+}
+
+func TestRoleTrust_ActionsAndDenies(t *testing.T) {
+	trustPolicy := parseTestPolicy(t, `{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Principal": {"AWS": ["arn:aws:iam::123456789012:user/alice", "arn:aws:iam::123456789012:user/bob"]},
+				"Action": "sts:*"
+			},
+			{
+				"Effect": "Allow",
+				"Principal": {"AWS": "arn:aws:iam::123456789012:role/deployer"},
+				"Action": ["sts:AssumeRole*"],
+				"Condition": {"StringEquals": {"sts:ExternalId": "secret"}}
+			},
+			{
+				"Effect": "Allow",
+				"Principal": {"AWS": "arn:aws:iam::123456789012:user/carol"},
+				"Action": "sts:TagSession"
+			},
+			{
+				"Effect": "Deny",
+				"Principal": {"AWS": "arn:aws:iam::123456789012:user/bob"},
+				"Action": "sts:AssumeRole"
+			},
+			{
+				"Effect": "Deny",
+				"Principal": "*",
+				"Action": "sts:AssumeRole",
+				"Condition": {"Bool": {"aws:MultiFactorAuthPresent": false}}
+			}
+		]
+	}`)
+
+	trust := (&EKSClient{}).roleTrust(trustPolicy, "arn:aws:iam::123456789012:role/test-role", nil)
+
+	mfaDeny := []PolicyCondition{{Operator: "Bool", Key: "aws:MultiFactorAuthPresent", Values: []string{"false"}}}
+	assert.Equal(t, []TrustedPrincipal{
+		{
+			ARN:            "arn:aws:iam::123456789012:user/alice",
+			SessionTags:    true,
+			DenyConditions: mfaDeny,
+		},
+		{
+			ARN:            "arn:aws:iam::123456789012:role/deployer",
+			Conditions:     []PolicyCondition{{Operator: "StringEquals", Key: "sts:ExternalId", Values: []string{"secret"}}},
+			DenyConditions: mfaDeny,
+		},
+	}, trust.Principals)
+}
+
+func TestRoleTrust_AccountRoot(t *testing.T) {
+	trustPolicy := parseTestPolicy(t, `{
+		"Version": "2012-10-17",
+		"Statement": {
+			"Effect": "Allow",
+			"Principal": {"AWS": ["123456789012", "arn:aws:iam::210987654321:root"]},
+			"Action": "sts:AssumeRole"
+		}
+	}`)
+	roleARN := "arn:aws:iam::123456789012:role/test-role"
+
+	allowAssume := parseTestPolicy(t, `{
+		"Version": "2012-10-17",
+		"Statement": [{"Effect": "Allow", "Action": "sts:AssumeRole", "Resource": "arn:aws:iam::123456789012:role/test-*"}]
+	}`)
+	allowOther := parseTestPolicy(t, `{
+		"Version": "2012-10-17",
+		"Statement": [{"Effect": "Allow", "Action": "sts:AssumeRole", "Resource": "arn:aws:iam::123456789012:role/other"}]
+	}`)
+	denyAll := parseTestPolicy(t, `{
+		"Version": "2012-10-17",
+		"Statement": [{"Effect": "Deny", "Action": "*", "Resource": "*"}]
+	}`)
+	users := []*userIdentityPolicies{
+		{ARN: "arn:aws:iam::123456789012:user/alice", Policies: []*PolicyDocument{allowAssume}},
+		{ARN: "arn:aws:iam::123456789012:user/bob", Policies: []*PolicyDocument{allowOther}},
+		{ARN: "arn:aws:iam::123456789012:user/carol", Policies: []*PolicyDocument{allowAssume, denyAll}},
+	}
+
+	trust := (&EKSClient{}).roleTrust(trustPolicy, roleARN, users)
+	assert.Equal(t, []TrustedPrincipal{
+		// The other account can't be expanded, as its users aren't known.
+		{ARN: "arn:aws:iam::210987654321:root"},
+		{ARN: "arn:aws:iam::123456789012:user/alice", Via: "arn:aws:iam::123456789012:root"},
+	}, trust.Principals)
+
+	// Without the users, the account is kept.
+	trust = (&EKSClient{}).roleTrust(trustPolicy, roleARN, nil)
+	require.Len(t, trust.Principals, 2)
+	assert.Equal(t, "arn:aws:iam::123456789012:root", trust.Principals[0].ARN)
+}
+
+func TestParseMapAccounts(t *testing.T) {
+	accounts, err := parseMapAccounts("- \"123456789012\"\n- 210987654321\n- 012345678901\n- \"012345678902\"\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"123456789012", "210987654321", "012345678901", "012345678902"}, accounts)
+
+	_, err = parseMapAccounts("- userarn: arn:aws:iam::123456789012:user/alice\n")
+	assert.Error(t, err)
+}
+
+func TestGetAwsAuthMappings_InvalidMapAccounts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "aws-auth", Namespace: "kube-system"},
+			Data: map[string]string{
+				"mapUsers":    "- userarn: arn:aws:iam::123456789012:user/alice\n  username: alice\n",
+				"mapAccounts": "- userarn: arn:aws:iam::123456789012:user/alice\n",
+			},
+		}))
+	}))
+	t.Cleanup(server.Close)
+	c, err := NewEKSClient(&rest.Config{
+		Host:          server.URL,
+		ContentConfig: rest.ContentConfig{ContentType: "application/json"},
+		QPS:           -1,
+	}, nil, nil, testClusterName, 0)
+	require.NoError(t, err)
+
+	// Invalid mapAccounts are ignored without dropping the user and role mappings.
+	users, _, accounts, err := c.getAwsAuthMappings(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"alice": {"arn:aws:iam::123456789012:user/alice"}}, users)
+	assert.Empty(t, accounts)
+}
+
+func TestLookupArnsByUsername_MappedAccounts(t *testing.T) {
+	c := &EKSClient{
+		cacheUsersMap: map[string][]string{
+			"alice": {"arn:aws:iam::123456789012:user/alice"},
+		},
+		cacheGroupsMap:      map[string][]string{},
+		cacheMappedAccounts: map[string]bool{"123456789012": true},
+		cacheMappedARNs:     map[string]bool{"arn:aws:iam::123456789012:user/alice": true},
+		idCacheExpiry:       time.Now().Add(time.Hour),
+	}
+	ctx := context.Background()
+
+	matches, err := c.LookupArnsByUsername(ctx, "arn:aws:iam::123456789012:user/bob")
+	require.NoError(t, err)
+	assert.Equal(t, []UsernameMatch{{ARN: "arn:aws:iam::123456789012:user/bob"}}, matches)
+
+	matches, err = c.LookupArnsByUsername(ctx, "arn:aws:iam::123456789012:role/deployer")
+	require.NoError(t, err)
+	assert.Equal(t, []UsernameMatch{{ARN: "arn:aws:iam::123456789012:role/deployer"}}, matches)
+
+	for _, username := range []string{
+		// Explicit mappings take precedence over the account's mapping.
+		"arn:aws:iam::123456789012:user/alice",
+		// Roles of mapped accounts are mapped without their path.
+		"arn:aws:iam::123456789012:role/ci/deployer",
+		"arn:aws:iam::210987654321:user/bob",
+		"bob",
+	} {
+		matches, err = c.LookupArnsByUsername(ctx, username)
+		require.NoError(t, err)
+		assert.Empty(t, matches, username)
+	}
+
+	accounts, err := c.ListMappedAccounts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"123456789012"}, accounts)
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// DescribeCluster returns the current configuration of the cluster.
+func (c *EKSClient) DescribeCluster(ctx context.Context) (*eksTypes.Cluster, error) {
+	result, err := c.eksClient.DescribeCluster(ctx, &eks.DescribeClusterInput{
+		Name: aws.String(c.clusterName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe cluster %s: %w", c.clusterName, err)
+	}
+	if result.Cluster == nil {
+		return nil, fmt.Errorf("EKS cluster %s not found", c.clusterName)
+	}
+	return result.Cluster, nil
+}
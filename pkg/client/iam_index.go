@@ -0,0 +1,248 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const iamRolesPageSize = 100
+
+// iamIndex is a snapshot of the IAM roles and users of the account with their policies,
+// fetched in bulk with GetAccountAuthorizationDetails.
+type iamIndex struct {
+	roles []*IAMRole
+	// trustPolicies are the trust policies of the roles, by role name.
+	trustPolicies map[string]*TrustPolicy
+	roleARNs      map[string]string
+	// roleARNsByAWSAuthARN are the role ARNs by their ARN without path, as aws-auth maps them.
+	roleARNsByAWSAuthARN map[string]string
+	users                []*userIdentityPolicies
+	// userTags are the tags of the users, by user ARN.
+	userTags map[string]map[string]string
+
+	// trusts are the evaluated trust policies, by role name.
+	trustsMtx sync.Mutex
+	trusts    map[string]*RoleTrust
+}
+
+// userIdentityPolicies are the identity policies of an IAM user, including the policies of its groups.
+type userIdentityPolicies struct {
+	ARN      string
+	Policies []*PolicyDocument
+}
+
+// loadIAMIndex returns the IAM index, fetching it if it wasn't yet or refresh is set.
+// The index is refreshed at the start of each sync, so that listing roles and their grants read the same snapshot.
+func (c *EKSClient) loadIAMIndex(ctx context.Context, refresh bool) (*iamIndex, error) {
+	c.iamIndexMutex.Lock()
+	defer c.iamIndexMutex.Unlock()
+
+	if c.iamIndex != nil && !refresh {
+		return c.iamIndex, nil
+	}
+
+	var details iam.GetAccountAuthorizationDetailsOutput
+	paginator := iam.NewGetAccountAuthorizationDetailsPaginator(c.iamClient, &iam.GetAccountAuthorizationDetailsInput{
+		Filter: []iamTypes.EntityType{
+			iamTypes.EntityTypeRole,
+			iamTypes.EntityTypeUser,
+			iamTypes.EntityTypeGroup,
+			iamTypes.EntityTypeLocalManagedPolicy,
+			iamTypes.EntityTypeAWSManagedPolicy,
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account authorization details: %w", err)
+		}
+		details.RoleDetailList = append(details.RoleDetailList, page.RoleDetailList...)
+		details.UserDetailList = append(details.UserDetailList, page.UserDetailList...)
+		details.GroupDetailList = append(details.GroupDetailList, page.GroupDetailList...)
+		details.Policies = append(details.Policies, page.Policies...)
+	}
+
+	c.iamIndex = newIAMIndex(ctx, &details)
+	return c.iamIndex, nil
+}
+
+// newIAMIndex indexes the account authorization details. Roles that miss information and
+// policies that can't be parsed are skipped.
+func newIAMIndex(ctx context.Context, details *iam.GetAccountAuthorizationDetailsOutput) *iamIndex {
+	l := ctxzap.Extract(ctx)
+
+	index := &iamIndex{
+		trustPolicies: make(map[string]*TrustPolicy),
+		roleARNs:      make(map[string]string),
+		trusts:        make(map[string]*RoleTrust),
+
+		roleARNsByAWSAuthARN: make(map[string]string),
+		userTags:             make(map[string]map[string]string),
+	}
+
+	for _, role := range details.RoleDetailList {
+		if role.RoleName == nil || role.RoleId == nil || role.Arn == nil {
+			l.Warn("missing information, skipping IAM role", zap.String("role_name", aws.ToString(role.RoleName)))
+			continue
+		}
+		tags := make(map[string]string, len(role.Tags))
+		for _, tag := range role.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		index.roles = append(index.roles, &IAMRole{
+			RoleName:   *role.RoleName,
+			RoleID:     *role.RoleId,
+			ARN:        *role.Arn,
+			CreateDate: role.CreateDate,
+			Path:       role.Path,
+			Tags:       tags,
+		})
+		index.roleARNs[*role.RoleName] = *role.Arn
+		index.roleARNsByAWSAuthARN[AWSAuthRoleARN(*role.Arn)] = *role.Arn
+
+		if role.AssumeRolePolicyDocument == nil {
+			continue
+		}
+		trustPolicy, err := parsePolicyDocument(*role.AssumeRolePolicyDocument)
+		if err != nil {
+			l.Warn("failed to parse trust policy",
+				zap.String("role_name", *role.RoleName),
+				zap.Error(err))
+			continue
+		}
+		index.trustPolicies[*role.RoleName] = trustPolicy
+	}
+
+	groups := make(map[string]iamTypes.GroupDetail, len(details.GroupDetailList))
+	for _, group := range details.GroupDetailList {
+		groups[aws.ToString(group.GroupName)] = group
+	}
+	managedPolicies := make(map[string]iamTypes.ManagedPolicyDetail, len(details.Policies))
+	for _, policy := range details.Policies {
+		managedPolicies[aws.ToString(policy.Arn)] = policy
+	}
+
+	for _, user := range details.UserDetailList {
+		policies := &userIdentityPolicies{ARN: aws.ToString(user.Arn)}
+		policies.add(ctx, user.UserPolicyList, user.AttachedManagedPolicies, managedPolicies)
+		for _, groupName := range user.GroupList {
+			group, ok := groups[groupName]
+			if !ok {
+				continue
+			}
+			policies.add(ctx, group.GroupPolicyList, group.AttachedManagedPolicies, managedPolicies)
+		}
+		index.users = append(index.users, policies)
+
+		tags := make(map[string]string, len(user.Tags))
+		for _, tag := range user.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		index.userTags[aws.ToString(user.Arn)] = tags
+	}
+
+	return index
+}
+
+// rolesPage returns a page of the roles, starting at the offset given by the page token.
+func (x *iamIndex) rolesPage(pageToken string) ([]*IAMRole, *string, error) {
+	offset := 0
+	if pageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 || offset > len(x.roles) {
+			return nil, nil, fmt.Errorf("invalid IAM roles page token: %s", pageToken)
+		}
+	}
+
+	end := min(offset+iamRolesPageSize, len(x.roles))
+	if end == len(x.roles) {
+		return x.roles[offset:end], nil, nil
+	}
+	return x.roles[offset:end], aws.String(strconv.Itoa(end)), nil
+}
+
+// ResolveRoleARN returns the full ARN of a role mapped by aws-auth, which omits the role path.
+// Other ARNs, and roles that aren't in the IAM index, such as those of other accounts, are returned unchanged.
+func (c *EKSClient) ResolveRoleARN(ctx context.Context, roleARN string) (string, error) {
+	role, err := ParseIAMPrincipalARN(roleARN)
+	if err != nil || role.Type != "role" || role.Path != "/" {
+		return roleARN, nil
+	}
+	index, err := c.loadIAMIndex(ctx, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve role ARN %s: %w", roleARN, err)
+	}
+	if resolved, ok := index.roleARNsByAWSAuthARN[roleARN]; ok {
+		return resolved, nil
+	}
+	return roleARN, nil
+}
+
+// roleTrust returns the evaluated trust policy of a role, or false if the role isn't in the index.
+func (x *iamIndex) roleTrust(c *EKSClient, roleName string) (*RoleTrust, bool) {
+	x.trustsMtx.Lock()
+	defer x.trustsMtx.Unlock()
+
+	if trust, ok := x.trusts[roleName]; ok {
+		return trust, true
+	}
+	roleARN, ok := x.roleARNs[roleName]
+	if !ok {
+		return nil, false
+	}
+
+	trust := &RoleTrust{}
+	if trustPolicy, ok := x.trustPolicies[roleName]; ok {
+		trust = c.roleTrust(trustPolicy, roleARN, x.users)
+	}
+	x.trusts[roleName] = trust
+	return trust, true
+}
+
+// add adds the inline policies and the default versions of the attached managed policies.
+// Policies that can't be parsed are skipped.
+func (u *userIdentityPolicies) add(
+	ctx context.Context,
+	inline []iamTypes.PolicyDetail,
+	attached []iamTypes.AttachedPolicy,
+	managedPolicies map[string]iamTypes.ManagedPolicyDetail,
+) {
+	l := ctxzap.Extract(ctx)
+
+	documents := make(map[string]string)
+	for _, policy := range inline {
+		documents[aws.ToString(policy.PolicyName)] = aws.ToString(policy.PolicyDocument)
+	}
+	for _, attachment := range attached {
+		policy, ok := managedPolicies[aws.ToString(attachment.PolicyArn)]
+		if !ok {
+			continue
+		}
+		for _, version := range policy.PolicyVersionList {
+			if version.IsDefaultVersion {
+				documents[aws.ToString(policy.Arn)] = aws.ToString(version.Document)
+			}
+		}
+	}
+
+	for name, document := range documents {
+		policy, err := parsePolicyDocument(document)
+		if err != nil {
+			l.Warn("failed to parse identity policy",
+				zap.String("principal_arn", u.ARN),
+				zap.String("policy", name),
+				zap.Error(err))
+			continue
+		}
+		u.Policies = append(u.Policies, policy)
+	}
+}
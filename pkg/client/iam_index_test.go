@@ -0,0 +1,125 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIAMIndex(t *testing.T) {
+	assumeRoles := url.QueryEscape(`{"Statement": [{"Effect": "Allow", "Action": "sts:AssumeRole", "Resource": "*"}]}`)
+	details := &iam.GetAccountAuthorizationDetailsOutput{
+		RoleDetailList: []iamTypes.RoleDetail{
+			{
+				RoleName:                 aws.String("deployer"),
+				RoleId:                   aws.String("AROADEPLOYER"),
+				Arn:                      aws.String("arn:aws:iam::123456789012:role/deployer"),
+				AssumeRolePolicyDocument: aws.String(url.QueryEscape(`{"Statement": [{"Effect": "Allow", "Principal": {"AWS": "123456789012"}, "Action": "sts:AssumeRole"}]}`)),
+			},
+			{
+				RoleName: aws.String("incomplete"),
+			},
+		},
+		UserDetailList: []iamTypes.UserDetail{
+			{
+				Arn:       aws.String("arn:aws:iam::123456789012:user/alice"),
+				GroupList: []string{"deployers"},
+				Tags:      []iamTypes.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+			},
+			{
+				Arn: aws.String("arn:aws:iam::123456789012:user/bob"),
+			},
+		},
+		GroupDetailList: []iamTypes.GroupDetail{
+			{
+				GroupName: aws.String("deployers"),
+				AttachedManagedPolicies: []iamTypes.AttachedPolicy{
+					{PolicyArn: aws.String("arn:aws:iam::123456789012:policy/assume-roles")},
+				},
+			},
+		},
+		Policies: []iamTypes.ManagedPolicyDetail{
+			{
+				Arn: aws.String("arn:aws:iam::123456789012:policy/assume-roles"),
+				PolicyVersionList: []iamTypes.PolicyVersion{
+					{Document: aws.String(assumeRoles), IsDefaultVersion: true},
+				},
+			},
+		},
+	}
+
+	index := newIAMIndex(t.Context(), details)
+	require.Len(t, index.roles, 1)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/deployer", index.roles[0].ARN)
+	require.Len(t, index.users, 2)
+	assert.Len(t, index.users[0].Policies, 1)
+	assert.Empty(t, index.users[1].Policies)
+	assert.Equal(t, map[string]string{"team": "platform"}, index.userTags["arn:aws:iam::123456789012:user/alice"])
+	assert.Empty(t, index.userTags["arn:aws:iam::123456789012:user/bob"])
+
+	// The account trust expands to the users whose group lets them assume roles.
+	trust, ok := index.roleTrust(&EKSClient{}, "deployer")
+	require.True(t, ok)
+	assert.Equal(t, []TrustedPrincipal{
+		{ARN: "arn:aws:iam::123456789012:user/alice", Via: "arn:aws:iam::123456789012:root"},
+	}, trust.Principals)
+
+	cached, _ := index.roleTrust(&EKSClient{}, "deployer")
+	assert.Same(t, trust, cached)
+
+	_, ok = index.roleTrust(&EKSClient{}, "missing")
+	assert.False(t, ok)
+}
+
+func TestIAMIndex_RolesPage(t *testing.T) {
+	index := &iamIndex{}
+	for i := 0; i < iamRolesPageSize+1; i++ {
+		index.roles = append(index.roles, &IAMRole{RoleName: fmt.Sprintf("role-%d", i)})
+	}
+
+	roles, next, err := index.rolesPage("")
+	require.NoError(t, err)
+	assert.Len(t, roles, iamRolesPageSize)
+	require.NotNil(t, next)
+
+	roles, next, err = index.rolesPage(*next)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, fmt.Sprintf("role-%d", iamRolesPageSize), roles[0].RoleName)
+	assert.Nil(t, next)
+
+	_, _, err = index.rolesPage("not-an-offset")
+	assert.Error(t, err)
+}
+
+func TestResolveRoleARN(t *testing.T) {
+	c := &EKSClient{
+		iamIndex: newIAMIndex(t.Context(), &iam.GetAccountAuthorizationDetailsOutput{
+			RoleDetailList: []iamTypes.RoleDetail{
+				{
+					RoleName: aws.String("deployer"),
+					RoleId:   aws.String("AROADEPLOYER"),
+					Arn:      aws.String("arn:aws:iam::123456789012:role/ci/deployer"),
+				},
+			},
+		}),
+	}
+
+	for roleARN, want := range map[string]string{
+		"arn:aws:iam::123456789012:role/deployer":    "arn:aws:iam::123456789012:role/ci/deployer",
+		"arn:aws:iam::123456789012:role/ci/deployer": "arn:aws:iam::123456789012:role/ci/deployer",
+		// Roles of other accounts aren't in the index.
+		"arn:aws:iam::210987654321:role/deployer": "arn:aws:iam::210987654321:role/deployer",
+		"arn:aws:iam::123456789012:user/deployer": "arn:aws:iam::123456789012:user/deployer",
+	} {
+		resolved, err := c.ResolveRoleARN(t.Context(), roleARN)
+		require.NoError(t, err)
+		assert.Equal(t, want, resolved, roleARN)
+	}
+}
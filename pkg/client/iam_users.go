@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// ListIAMUsers lists IAM users with pagination support.
+// The listing doesn't include tags or MFA devices, see GetIAMUserDetails.
+func (c *EKSClient) ListIAMUsers(ctx context.Context, nextToken *string) ([]*IAMUser, *string, error) {
+	input := &iam.ListUsersInput{
+		MaxItems: aws.Int32(100),
+	}
+	if nextToken != nil && *nextToken != "" {
+		input.Marker = nextToken
+	}
+	page, err := c.iamClient.ListUsers(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list IAM users: %w", err)
+	}
+
+	var users []*IAMUser
+	for _, user := range page.Users {
+		if user.UserName == nil || user.UserId == nil || user.Arn == nil {
+			continue
+		}
+		users = append(users, &IAMUser{
+			UserName:         *user.UserName,
+			UserID:           *user.UserId,
+			ARN:              *user.Arn,
+			Path:             user.Path,
+			CreateDate:       user.CreateDate,
+			PasswordLastUsed: user.PasswordLastUsed,
+		})
+	}
+
+	return users, page.Marker, nil
+}
+
+// GetIAMUserDetails fills in the tags and MFA status of an IAM user. Tags are read from the IAM index,
+// so that only the MFA devices are listed for each user.
+func (c *EKSClient) GetIAMUserDetails(ctx context.Context, user *IAMUser) error {
+	index, err := c.loadIAMIndex(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to get tags of IAM user %s: %w", user.UserName, err)
+	}
+
+	mfaDevices, err := c.iamClient.ListMFADevices(ctx, &iam.ListMFADevicesInput{
+		UserName: aws.String(user.UserName),
+		MaxItems: aws.Int32(1),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list MFA devices of IAM user %s: %w", user.UserName, err)
+	}
+
+	// Users created since the index was fetched have no tags until it's refreshed.
+	user.Tags = index.userTags[user.ARN]
+	user.MFAEnabled = len(mfaDevices.MFADevices) > 0
+	return nil
+}
@@ -11,9 +11,55 @@ import (
 // AccessPolicyClient defines the interface for EKS client methods needed by the access policy builder.
 type AccessPolicyClient interface {
 	ListNamespaces(ctx context.Context, opts metav1.ListOptions) (*corev1.NamespaceList, error)
-	GetAccessEntriesWithPolicy(ctx context.Context, policyARN string, nextToken *string) ([]string, *string, error)
+	ListAccessPolicies(ctx context.Context) ([]*AccessPolicy, error)
+	AccessEntries(ctx context.Context, refresh bool) (*AccessEntrySnapshot, error)
 	GetAssociatedAccessPolicies(ctx context.Context, principalARN string) ([]eksTypes.AssociatedAccessPolicy, error)
 	CreateAccessEntry(ctx context.Context, principalARN string) (*eksTypes.AccessEntry, error)
 	AssociateAccessPolicy(ctx context.Context, principalARN string, policyARN string, accessScope *eksTypes.AccessScope) error
 	DisassociateAccessPolicy(ctx context.Context, principalARN string, policyARN string) error
 }
+
+// ClusterClient defines the interface for EKS client methods needed by the cluster builder.
+type ClusterClient interface {
+	DescribeCluster(ctx context.Context) (*eksTypes.Cluster, error)
+}
+
+// AccessEntryClient defines the interface for EKS client methods needed by the access entry builder.
+type AccessEntryClient interface {
+	AccessEntries(ctx context.Context, refresh bool) (*AccessEntrySnapshot, error)
+	DescribeAccessEntry(ctx context.Context, principalARN string) (*AccessEntry, error)
+	CheckAccessEntryAccess(ctx context.Context) error
+}
+
+// IdentityClient defines the interface for EKS client methods needed by the Kubernetes group builder.
+type IdentityClient interface {
+	ListKubernetesGroups(ctx context.Context) ([]string, error)
+	ListMappedPrincipals(ctx context.Context) ([]string, error)
+	ListMappedAccounts(ctx context.Context) ([]string, error)
+	LookupArnsByGroup(ctx context.Context, group string) ([]string, error)
+	LookupArnsByUsername(ctx context.Context, username string) ([]UsernameMatch, error)
+}
+
+// RoleARNResolver resolves the role ARNs of aws-auth, which omit the role path, to the ARNs of the roles.
+type RoleARNResolver interface {
+	ResolveRoleARN(ctx context.Context, roleARN string) (string, error)
+}
+
+// PermissionClient defines the interface for IAM client methods needed to validate the connector's AWS permissions.
+type PermissionClient interface {
+	CheckActionsAllowed(ctx context.Context, principalARN string, resourceARN string, actions ...string) error
+}
+
+// IAMUserClient defines the interface for IAM client methods needed by the IAM user builder.
+type IAMUserClient interface {
+	ListIAMUsers(ctx context.Context, nextToken *string) ([]*IAMUser, *string, error)
+	GetIAMUserDetails(ctx context.Context, user *IAMUser) error
+	ListRoleTrustPrincipals(ctx context.Context) ([]string, error)
+}
+
+// PodIdentityClient defines the interface for EKS client methods needed to sync and provision pod identity associations.
+type PodIdentityClient interface {
+	ListPodIdentityAssociations(ctx context.Context) ([]*PodIdentityAssociation, error)
+	CreatePodIdentityAssociation(ctx context.Context, namespace string, serviceAccount string, roleARN string) error
+	DeletePodIdentityAssociation(ctx context.Context, associationID string) error
+}
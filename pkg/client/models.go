@@ -1,6 +1,13 @@
 package client
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
 
 // Structs for parsing mapUsers/mapRoles entries.
 type mapUser struct {
@@ -15,6 +22,25 @@ type mapRole struct {
 	Groups   []string `yaml:"groups,omitempty"`
 }
 
+// mapAccountID returns the account ID of a mapAccounts entry: an AWS account whose IAM users and roles are
+// all mapped, each to its own ARN as username. Unquoted account IDs parse as numbers, so leading zeros lost
+// that way are restored.
+func mapAccountID(entry interface{}) (string, error) {
+	var id string
+	switch v := entry.(type) {
+	case string:
+		id = v
+	case float64:
+		id = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return "", fmt.Errorf("invalid mapAccounts entry %v", entry)
+	}
+	if len(id) < 12 {
+		id = strings.Repeat("0", 12-len(id)) + id
+	}
+	return id, nil
+}
+
 // AccessEntry represents an EKS access entry.
 type AccessEntry struct {
 	AccessEntryArn   string
@@ -26,6 +52,8 @@ type AccessEntry struct {
 	Tags             map[string]string
 	Type             string
 	Username         string
+	// AssociatedPolicies are the access policies associated to the entry, with their scopes.
+	AssociatedPolicies []eksTypes.AssociatedAccessPolicy
 }
 
 // AccessPolicy represents an EKS access policy.
@@ -54,6 +82,7 @@ type EKSConfig struct {
 	Region            string
 	Endpoint          string
 	CAData            []byte
+	Tags              map[string]string
 }
 
 // IAMRole represents an AWS IAM role.
@@ -63,4 +92,26 @@ type IAMRole struct {
 	ARN        string
 	CreateDate *time.Time
 	Path       *string
+	Tags       map[string]string
+}
+
+// IAMUser represents an AWS IAM user.
+type IAMUser struct {
+	UserName         string
+	UserID           string
+	ARN              string
+	Path             *string
+	CreateDate       *time.Time
+	PasswordLastUsed *time.Time
+	Tags             map[string]string
+	MFAEnabled       bool
+}
+
+// PodIdentityAssociation represents an EKS pod identity association between a service account and an IAM role.
+type PodIdentityAssociation struct {
+	AssociationID  string
+	AssociationARN string
+	Namespace      string
+	ServiceAccount string
+	RoleARN        string
 }
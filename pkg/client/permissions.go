@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// CheckActionsAllowed simulates the IAM policies of the principal to check that it's allowed to call the actions
+// on the resource, without calling them.
+func (c *EKSClient) CheckActionsAllowed(ctx context.Context, principalARN string, resourceARN string, actions ...string) error {
+	var denied []string
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(c.iamClient, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalARN),
+		ActionNames:     actions,
+		ResourceArns:    []string{resourceARN},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to simulate the policies of %s: %w", principalARN, err)
+		}
+		for _, result := range page.EvaluationResults {
+			if result.EvalDecision != iamTypes.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, fmt.Sprintf("%s (%s)", aws.ToString(result.EvalActionName), result.EvalDecision))
+			}
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("%s isn't allowed %s on %s", principalARN, strings.Join(denied, ", "), resourceARN)
+	}
+	return nil
+}
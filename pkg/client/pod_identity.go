@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// ListPodIdentityAssociations lists the pod identity associations of the cluster, with the IAM role of each.
+// The listing doesn't include the role, so each association is described.
+func (c *EKSClient) ListPodIdentityAssociations(ctx context.Context) ([]*PodIdentityAssociation, error) {
+	paginator := eks.NewListPodIdentityAssociationsPaginator(c.eksClient, &eks.ListPodIdentityAssociationsInput{
+		ClusterName: aws.String(c.clusterName),
+	})
+
+	var associations []*PodIdentityAssociation
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pod identity associations: %w", err)
+		}
+		for _, summary := range page.Associations {
+			describeResult, err := c.eksClient.DescribePodIdentityAssociation(ctx, &eks.DescribePodIdentityAssociationInput{
+				ClusterName:   aws.String(c.clusterName),
+				AssociationId: summary.AssociationId,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe pod identity association %s: %w", aws.ToString(summary.AssociationId), err)
+			}
+			association := describeResult.Association
+			if association == nil {
+				continue
+			}
+			associations = append(associations, &PodIdentityAssociation{
+				AssociationID:  aws.ToString(association.AssociationId),
+				AssociationARN: aws.ToString(association.AssociationArn),
+				Namespace:      aws.ToString(association.Namespace),
+				ServiceAccount: aws.ToString(association.ServiceAccount),
+				RoleARN:        aws.ToString(association.RoleArn),
+			})
+		}
+	}
+
+	return associations, nil
+}
+
+// CreatePodIdentityAssociation lets the pods of a service account assume an IAM role.
+func (c *EKSClient) CreatePodIdentityAssociation(ctx context.Context, namespace string, serviceAccount string, roleARN string) error {
+	_, err := c.eksClient.CreatePodIdentityAssociation(ctx, &eks.CreatePodIdentityAssociationInput{
+		ClusterName:    aws.String(c.clusterName),
+		Namespace:      aws.String(namespace),
+		ServiceAccount: aws.String(serviceAccount),
+		RoleArn:        aws.String(roleARN),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pod identity association: %w", err)
+	}
+	return nil
+}
+
+// DeletePodIdentityAssociation deletes a pod identity association.
+func (c *EKSClient) DeletePodIdentityAssociation(ctx context.Context, associationID string) error {
+	_, err := c.eksClient.DeletePodIdentityAssociation(ctx, &eks.DeletePodIdentityAssociationInput{
+		ClusterName:   aws.String(c.clusterName),
+		AssociationId: aws.String(associationID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete pod identity association: %w", err)
+	}
+	return nil
+}
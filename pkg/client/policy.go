@@ -0,0 +1,335 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	actionAssumeRole                = "sts:AssumeRole"
+	actionAssumeRoleWithWebIdentity = "sts:AssumeRoleWithWebIdentity"
+	actionTagSession                = "sts:TagSession"
+)
+
+// PolicyDocument represents the structure of an IAM policy, such as a role trust policy or an identity policy.
+type PolicyDocument struct {
+	Version   string     `json:"Version"`
+	Statement Statements `json:"Statement"`
+}
+
+// Statements are the statements of a policy, which may be given as a single statement object.
+type Statements []Statement
+
+func (s *Statements) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var statement Statement
+		if err := json.Unmarshal(trimmed, &statement); err != nil {
+			return err
+		}
+		*s = Statements{statement}
+		return nil
+	}
+
+	var statements []Statement
+	if err := json.Unmarshal(data, &statements); err != nil {
+		return err
+	}
+	*s = statements
+	return nil
+}
+
+// Statement represents a statement in a policy.
+type Statement struct {
+	Sid          string                 `json:"Sid,omitempty"`
+	Effect       string                 `json:"Effect"`
+	Principal    PolicyPrincipal        `json:"Principal,omitempty"`
+	NotPrincipal PolicyPrincipal        `json:"NotPrincipal,omitempty"`
+	Action       interface{}            `json:"Action,omitempty"`
+	NotAction    interface{}            `json:"NotAction,omitempty"`
+	Resource     interface{}            `json:"Resource,omitempty"`
+	NotResource  interface{}            `json:"NotResource,omitempty"`
+	Condition    map[string]interface{} `json:"Condition,omitempty"`
+}
+
+// PolicyPrincipal is the Principal or NotPrincipal of a statement, by principal type such as AWS or Federated.
+// The "*" principal, which matches everyone, is decoded as {"AWS": "*"}.
+type PolicyPrincipal map[string]interface{}
+
+func (p *PolicyPrincipal) UnmarshalJSON(data []byte) error {
+	var everyone string
+	if err := json.Unmarshal(data, &everyone); err == nil {
+		*p = PolicyPrincipal{"AWS": everyone}
+		return nil
+	}
+
+	var principal map[string]interface{}
+	if err := json.Unmarshal(data, &principal); err != nil {
+		return err
+	}
+	*p = principal
+	return nil
+}
+
+// PolicyCondition is a single condition of a statement, such as StringEquals on sts:ExternalId.
+type PolicyCondition struct {
+	Operator string
+	Key      string
+	Values   []string
+}
+
+func (c PolicyCondition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Operator, c.Key, strings.Join(c.Values, ","))
+}
+
+// parsePolicyDocument parses a policy document as returned by the IAM API, which URL-encodes them.
+func parsePolicyDocument(document string) (*PolicyDocument, error) {
+	decoded, err := url.QueryUnescape(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to URL decode policy: %w", err)
+	}
+
+	var policy PolicyDocument
+	if err := json.Unmarshal([]byte(decoded), &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// parseConditions flattens the Condition block of a statement, sorted by operator and key.
+func parseConditions(condition map[string]interface{}) []PolicyCondition {
+	var conditions []PolicyCondition
+	for operator, keys := range condition {
+		keyValues, ok := keys.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, values := range keyValues {
+			conditions = append(conditions, PolicyCondition{
+				Operator: operator,
+				Key:      key,
+				Values:   conditionValues(values),
+			})
+		}
+	}
+	sort.Slice(conditions, func(i, j int) bool {
+		if conditions[i].Operator != conditions[j].Operator {
+			return conditions[i].Operator < conditions[j].Operator
+		}
+		return conditions[i].Key < conditions[j].Key
+	})
+	return conditions
+}
+
+// conditionValues extracts the values of a condition key, which can be a string, boolean, number or array.
+func conditionValues(values interface{}) []string {
+	switch v := values.(type) {
+	case []interface{}:
+		var rv []string
+		for _, value := range v {
+			rv = append(rv, fmt.Sprint(value))
+		}
+		return rv
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// appendConditions appends the conditions that aren't in conditions yet.
+func appendConditions(conditions []PolicyCondition, more ...PolicyCondition) []PolicyCondition {
+	for _, condition := range more {
+		duplicate := false
+		for _, existing := range conditions {
+			if existing.String() == condition.String() {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions
+}
+
+// matchesAction reports whether the Action, or NotAction, of the statement covers the action.
+// Action patterns may use IAM wildcards, such as sts:* or sts:AssumeRole*, and are case-insensitive.
+func (s Statement) matchesAction(action string) bool {
+	action = strings.ToLower(action)
+	if s.NotAction != nil {
+		for _, pattern := range extractPrincipalValues(s.NotAction) {
+			if MatchIAMWildcard(strings.ToLower(pattern), action) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, pattern := range extractPrincipalValues(s.Action) {
+		if MatchIAMWildcard(strings.ToLower(pattern), action) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResource reports whether the Resource, or NotResource, of the statement covers the resource ARN.
+// Statements without either, such as those of trust policies, apply to the resource the policy is attached to.
+func (s Statement) matchesResource(resource string) bool {
+	if s.NotResource != nil {
+		for _, pattern := range extractPrincipalValues(s.NotResource) {
+			if MatchIAMWildcard(pattern, resource) {
+				return false
+			}
+		}
+		return true
+	}
+	if s.Resource == nil {
+		return true
+	}
+	for _, pattern := range extractPrincipalValues(s.Resource) {
+		if MatchIAMWildcard(pattern, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPrincipal reports whether the Principal, or NotPrincipal, of the statement covers the AWS principal.
+func (s Statement) matchesPrincipal(principalARN string) bool {
+	partition := PartitionOf(principalARN)
+	if s.NotPrincipal != nil {
+		for _, pattern := range extractPrincipals(s.NotPrincipal) {
+			if principalMatches(normalizeAWSPrincipal(pattern, partition), principalARN) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, pattern := range extractPrincipals(s.Principal) {
+		if principalMatches(normalizeAWSPrincipal(pattern, partition), principalARN) {
+			return true
+		}
+	}
+	return false
+}
+
+// principalMatches reports whether a statement principal covers the principal: everyone ("*"),
+// the same ARN, or the root of the principal's account, which stands for every identity of the account.
+func principalMatches(pattern string, principalARN string) bool {
+	if pattern == "*" || pattern == principalARN {
+		return true
+	}
+	if account, ok := AccountRootID(pattern); ok {
+		return principalAccountID(principalARN) == account
+	}
+	return false
+}
+
+// normalizeAWSPrincipal returns the ARN of an AWS principal. A bare account ID stands for the root of the account
+// in the partition of the policy.
+func normalizeAWSPrincipal(principal string, partition string) string {
+	if len(principal) != 12 {
+		return principal
+	}
+	for _, r := range principal {
+		if r < '0' || r > '9' {
+			return principal
+		}
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:root", partition, principal)
+}
+
+// AccountRootID returns the account ID of an account root principal, such as arn:aws:iam::123456789012:root,
+// in any partition.
+func AccountRootID(principalARN string) (string, bool) {
+	parts := strings.Split(principalARN, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || parts[5] != "root" {
+		return "", false
+	}
+	return parts[4], true
+}
+
+// principalAccountID returns the account ID of a principal ARN.
+func principalAccountID(principalARN string) string {
+	parts := strings.Split(principalARN, ":")
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
+
+// evaluateIdentityPolicies reports whether the identity policies of a principal allow the action on the resource.
+// An unconditional Deny overrides any Allow. When only conditional statements allow the action, their conditions
+// are returned, and the conditions of Deny statements that may apply are returned as deny conditions.
+func evaluateIdentityPolicies(policies []*PolicyDocument, action string, resource string) (bool, []PolicyCondition, []PolicyCondition) {
+	allowed := false
+	unconditional := false
+	var conditions, denyConditions []PolicyCondition
+
+	for _, policy := range policies {
+		for _, statement := range policy.Statement {
+			if !statement.matchesAction(action) || !statement.matchesResource(resource) {
+				continue
+			}
+			statementConditions := parseConditions(statement.Condition)
+			switch statement.Effect {
+			case "Deny":
+				if len(statementConditions) == 0 {
+					return false, nil, nil
+				}
+				denyConditions = appendConditions(denyConditions, statementConditions...)
+			case "Allow":
+				allowed = true
+				if len(statementConditions) == 0 {
+					unconditional = true
+				} else {
+					conditions = appendConditions(conditions, statementConditions...)
+				}
+			}
+		}
+	}
+
+	if !allowed {
+		return false, nil, nil
+	}
+	if unconditional {
+		conditions = nil
+	}
+	return true, conditions, denyConditions
+}
+
+// MatchIAMWildcard matches a value against an IAM wildcard pattern, as used by StringLike conditions,
+// actions and resources, where * matches any sequence of characters and ? matches a single character.
+func MatchIAMWildcard(pattern string, value string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = pattern[1:]
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(value); i++ {
+				if MatchIAMWildcard(pattern, value[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if value == "" {
+				return false
+			}
+		default:
+			if value == "" || pattern[0] != value[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		value = value[1:]
+	}
+	return value == ""
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchIAMWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"*", "system:serviceaccount:default:builder", true},
+		{"system:serviceaccount:ci:*", "system:serviceaccount:ci:runner", true},
+		{"system:serviceaccount:ci:*", "system:serviceaccount:default:runner", false},
+		{"system:serviceaccount:*:runner-?", "system:serviceaccount:ci:runner-1", true},
+		{"system:serviceaccount:*:runner-?", "system:serviceaccount:ci:runner-10", false},
+		{"system:serviceaccount:ci:runner", "system:serviceaccount:ci:runner", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchIAMWildcard(tt.pattern, tt.value), "%s ~ %s", tt.pattern, tt.value)
+	}
+}
+
+func TestStatement_MatchesAction(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement Statement
+		action    string
+		want      bool
+	}{
+		{"exact", Statement{Action: "sts:AssumeRole"}, actionAssumeRole, true},
+		{"case insensitive", Statement{Action: "STS:assumerole"}, actionAssumeRole, true},
+		{"service wildcard", Statement{Action: "sts:*"}, actionTagSession, true},
+		{"prefix wildcard", Statement{Action: []interface{}{"sts:AssumeRole*"}}, actionAssumeRoleWithWebIdentity, true},
+		{"other action", Statement{Action: "sts:TagSession"}, actionAssumeRole, false},
+		{"not action", Statement{NotAction: "sts:TagSession"}, actionAssumeRole, true},
+		{"excluded by not action", Statement{NotAction: []interface{}{"sts:Assume*"}}, actionAssumeRole, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.statement.matchesAction(tt.action))
+		})
+	}
+}
+
+func TestStatement_MatchesPrincipal(t *testing.T) {
+	alice := "arn:aws:iam::123456789012:user/alice"
+
+	assert.True(t, Statement{Principal: PolicyPrincipal{"AWS": "*"}}.matchesPrincipal(alice))
+	assert.True(t, Statement{Principal: PolicyPrincipal{"AWS": "123456789012"}}.matchesPrincipal(alice))
+	assert.True(t, Statement{Principal: PolicyPrincipal{"AWS": []interface{}{alice}}}.matchesPrincipal(alice))
+	assert.False(t, Statement{Principal: PolicyPrincipal{"AWS": "arn:aws:iam::210987654321:root"}}.matchesPrincipal(alice))
+	assert.False(t, Statement{NotPrincipal: PolicyPrincipal{"AWS": alice}}.matchesPrincipal(alice))
+	assert.True(t, Statement{NotPrincipal: PolicyPrincipal{"AWS": alice}}.matchesPrincipal("arn:aws:iam::123456789012:user/bob"))
+
+	// Bare account IDs stand for the account root in the partition of the principal.
+	govAlice := "arn:aws-us-gov:iam::123456789012:user/alice"
+	assert.True(t, Statement{Principal: PolicyPrincipal{"AWS": "123456789012"}}.matchesPrincipal(govAlice))
+	assert.True(t, Statement{Principal: PolicyPrincipal{"AWS": "arn:aws-us-gov:iam::123456789012:root"}}.matchesPrincipal(govAlice))
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:root", normalizeAWSPrincipal("123456789012", "aws-us-gov"))
+}
+
+func TestEvaluateIdentityPolicies(t *testing.T) {
+	roleARN := "arn:aws:iam::123456789012:role/test-role"
+	conditional := parseTestPolicy(t, `{
+		"Statement": [{
+			"Effect": "Allow",
+			"Action": "sts:AssumeRole",
+			"Resource": "*",
+			"Condition": {"StringEquals": {"aws:RequestedRegion": "us-east-1"}}
+		}]
+	}`)
+	unconditional := parseTestPolicy(t, `{
+		"Statement": [{"Effect": "Allow", "Action": "sts:AssumeRole", "Resource": "arn:aws:iam::123456789012:role/*"}]
+	}`)
+	conditionalDeny := parseTestPolicy(t, `{
+		"Statement": [{
+			"Effect": "Deny",
+			"Action": "sts:*",
+			"NotResource": "arn:aws:iam::123456789012:role/other",
+			"Condition": {"Bool": {"aws:MultiFactorAuthPresent": "false"}}
+		}]
+	}`)
+
+	allowed, conditions, denyConditions := evaluateIdentityPolicies([]*PolicyDocument{conditional}, actionAssumeRole, roleARN)
+	assert.True(t, allowed)
+	assert.Equal(t, []PolicyCondition{{Operator: "StringEquals", Key: "aws:RequestedRegion", Values: []string{"us-east-1"}}}, conditions)
+	assert.Empty(t, denyConditions)
+
+	allowed, conditions, denyConditions = evaluateIdentityPolicies([]*PolicyDocument{conditional, unconditional, conditionalDeny}, actionAssumeRole, roleARN)
+	assert.True(t, allowed)
+	assert.Empty(t, conditions)
+	assert.Equal(t, []PolicyCondition{{Operator: "Bool", Key: "aws:MultiFactorAuthPresent", Values: []string{"false"}}}, denyConditions)
+
+	allowed, _, _ = evaluateIdentityPolicies([]*PolicyDocument{unconditional}, actionAssumeRole, "arn:aws:iam::210987654321:role/test-role")
+	assert.False(t, allowed)
+}
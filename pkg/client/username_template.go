@@ -0,0 +1,98 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// UsernameMatch is an IAM principal mapped to a Kubernetes username.
+type UsernameMatch struct {
+	ARN string
+	// SessionName is the role session the username identifies, when it matched a templated username
+	// such as sso:{{SessionName}}. It's empty for literal usernames.
+	SessionName string
+}
+
+// IsUsernameTemplate reports whether a mapped username is a template, such as sso:{{SessionName}},
+// that aws-iam-authenticator renders for each session.
+func IsUsernameTemplate(username string) bool {
+	return strings.Contains(username, "{{")
+}
+
+var usernameTemplatePlaceholder = regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+// usernameTemplatePatterns are the patterns of the values aws-iam-authenticator renders into templated usernames.
+// {{SessionName}} has the @ of the session name replaced with -, while {{SessionNameRaw}} keeps it.
+var usernameTemplatePatterns = map[string]string{
+	"SessionName":       `[\w+=,.-]+`,
+	"SessionNameRaw":    `[\w+=,.@-]+`,
+	"AccessKeyID":       `[A-Z0-9]+`,
+	"EC2PrivateDNSName": `[\w.-]+`,
+	"EC2InstanceID":     `i-[0-9a-f]+`,
+}
+
+// usernameTemplate matches the Kubernetes usernames rendered from the templated username of a principal.
+type usernameTemplate struct {
+	arn     string
+	pattern *regexp.Regexp
+}
+
+// compileUsernameTemplate compiles the templated username a principal is mapped to.
+// {{AccountID}} is the account of the principal itself.
+func compileUsernameTemplate(principalARN string, template string) (*usernameTemplate, error) {
+	principal, err := ParseIAMPrincipalARN(principalARN)
+	if err != nil {
+		return nil, err
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	hasSession := false
+	for _, loc := range usernameTemplatePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		last = loc[1]
+
+		name := template[loc[2]:loc[3]]
+		switch name {
+		case "AccountID":
+			pattern.WriteString(regexp.QuoteMeta(principal.AccountID))
+		case "SessionName", "SessionNameRaw":
+			// The first session placeholder is captured as the session name.
+			if !hasSession {
+				fmt.Fprintf(&pattern, "(?P<session>%s)", usernameTemplatePatterns[name])
+				hasSession = true
+			} else {
+				pattern.WriteString(usernameTemplatePatterns[name])
+			}
+		default:
+			value, ok := usernameTemplatePatterns[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported placeholder {{%s}} in username %s", name, template)
+			}
+			pattern.WriteString(value)
+		}
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+
+	compiled, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile username %s: %w", template, err)
+	}
+	return &usernameTemplate{arn: principalARN, pattern: compiled}, nil
+}
+
+// match returns the principal if the username was rendered from the template.
+func (t *usernameTemplate) match(username string) (UsernameMatch, bool) {
+	submatches := t.pattern.FindStringSubmatch(username)
+	if submatches == nil {
+		return UsernameMatch{}, false
+	}
+	match := UsernameMatch{ARN: t.arn}
+	if i := t.pattern.SubexpIndex("session"); i >= 0 {
+		match.SessionName = submatches[i]
+	}
+	return match, true
+}
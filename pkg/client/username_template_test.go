@@ -0,0 +1,82 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameTemplate_Match(t *testing.T) {
+	const ssoRole = "arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin_0123456789abcdef"
+
+	tests := []struct {
+		name        string
+		template    string
+		username    string
+		wantMatch   bool
+		wantSession string
+	}{
+		{
+			name:        "session name raw",
+			template:    "sso:{{SessionNameRaw}}",
+			username:    "sso:alice@corp.com",
+			wantMatch:   true,
+			wantSession: "alice@corp.com",
+		},
+		{
+			name:        "session name has @ replaced",
+			template:    "sso:{{SessionName}}",
+			username:    "sso:alice-corp.com",
+			wantMatch:   true,
+			wantSession: "alice-corp.com",
+		},
+		{
+			name:     "session name never contains @",
+			template: "sso:{{SessionName}}",
+			username: "sso:alice@corp.com",
+		},
+		{
+			name:        "account ID of the role",
+			template:    "{{AccountID}}:{{SessionNameRaw}}",
+			username:    "123456789012:alice@corp.com",
+			wantMatch:   true,
+			wantSession: "alice@corp.com",
+		},
+		{
+			name:     "account ID of another account",
+			template: "{{AccountID}}:{{SessionNameRaw}}",
+			username: "210987654321:alice@corp.com",
+		},
+		{
+			name:     "literal parts are quoted",
+			template: "sso.{{SessionName}}",
+			username: "ssoxalice",
+		},
+		{
+			name:      "without session",
+			template:  "node:{{EC2PrivateDNSName}}",
+			username:  "node:ip-10-0-0-1.ec2.internal",
+			wantMatch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := compileUsernameTemplate(ssoRole, tt.template)
+			require.NoError(t, err)
+
+			match, ok := template.match(tt.username)
+			assert.Equal(t, tt.wantMatch, ok)
+			if tt.wantMatch {
+				assert.Equal(t, ssoRole, match.ARN)
+				assert.Equal(t, tt.wantSession, match.SessionName)
+			}
+		})
+	}
+}
+
+func TestCompileUsernameTemplate_Unsupported(t *testing.T) {
+	_, err := compileUsernameTemplate("arn:aws:iam::123456789012:role/deployer", "{{Unknown}}")
+	assert.Error(t, err)
+}
@@ -15,6 +15,20 @@ type Eks struct {
 	EksSecretAccessKey string `mapstructure:"eks-secret-access-key"`
 	EksRegion string `mapstructure:"eks-region"`
 	EksClusterName string `mapstructure:"eks-cluster-name"`
+	EksClusterNames []string `mapstructure:"eks-cluster-names"`
+	EksSyncAllClusters bool `mapstructure:"eks-sync-all-clusters"`
+	EksDiscoveryRegions []string `mapstructure:"eks-discovery-regions"`
+	EksClusterNamePatterns []string `mapstructure:"eks-cluster-name-patterns"`
+	EksClusterTags []string `mapstructure:"eks-cluster-tags"`
+	EksRequestableAccessPolicies []string `mapstructure:"eks-requestable-access-policies"`
+	EksNonRequestableAccessPolicies []string `mapstructure:"eks-non-requestable-access-policies"`
+	EksPreferAccessEntries bool `mapstructure:"eks-prefer-access-entries"`
+	EksSyncIamUsers bool `mapstructure:"eks-sync-iam-users"`
+	EksReferencedIamUsersOnly bool `mapstructure:"eks-referenced-iam-users-only"`
+	EksRelevantIamRolesOnly bool `mapstructure:"eks-relevant-iam-roles-only"`
+	EksIamRolePathPrefixes []string `mapstructure:"eks-iam-role-path-prefixes"`
+	EksIamRoleTags []string `mapstructure:"eks-iam-role-tags"`
+	EksAccessEntryConcurrency int `mapstructure:"eks-access-entry-concurrency"`
 }
 
 func (c *Eks) findFieldByTag(tagValue string) (any, bool) {
@@ -44,10 +44,80 @@ var (
 	)
 	ClusterNameField = field.StringField(
 		"eks-cluster-name",
-		field.WithRequired(true),
 		field.WithDescription("The name of the EKS cluster to connect to"),
 		field.WithDisplayName("Cluster name"),
 	)
+	ClusterNamesField = field.StringSliceField(
+		"eks-cluster-names",
+		field.WithDescription("The names of the EKS clusters to connect to. Resource IDs are scoped by cluster"),
+		field.WithDisplayName("Cluster names"),
+	)
+	SyncAllClustersField = field.BoolField(
+		"eks-sync-all-clusters",
+		field.WithDescription("Discover and sync every EKS cluster in the discovery regions. Resource IDs are scoped by cluster"),
+		field.WithDisplayName("Sync all clusters"),
+	)
+	DiscoveryRegionsField = field.StringSliceField(
+		"eks-discovery-regions",
+		field.WithDescription("The regions to discover EKS clusters in. Defaults to the EKS region"),
+		field.WithDisplayName("Discovery regions"),
+	)
+	ClusterNamePatternsField = field.StringSliceField(
+		"eks-cluster-name-patterns",
+		field.WithDescription("Only sync discovered clusters whose name matches one of these glob patterns, e.g. prod-*"),
+		field.WithDisplayName("Cluster name patterns"),
+	)
+	ClusterTagsField = field.StringSliceField(
+		"eks-cluster-tags",
+		field.WithDescription("Only sync discovered clusters that have all of these tags, formatted as key=value or key"),
+		field.WithDisplayName("Cluster tags"),
+	)
+	RequestableAccessPoliciesField = field.StringSliceField(
+		"eks-requestable-access-policies",
+		field.WithDescription("The names or ARNs of the access policies that can be requested. Defaults to every access policy"),
+		field.WithDisplayName("Requestable access policies"),
+	)
+	NonRequestableAccessPoliciesField = field.StringSliceField(
+		"eks-non-requestable-access-policies",
+		field.WithDescription("The names or ARNs of the access policies that can't be requested. They are still synced"),
+		field.WithDisplayName("Non-requestable access policies"),
+	)
+	PreferAccessEntriesField = field.BoolField(
+		"eks-prefer-access-entries",
+		field.WithDescription("Map IAM principals to Kubernetes users with access entries instead of the aws-auth ConfigMap on clusters in API_AND_CONFIG_MAP authentication mode"),
+		field.WithDisplayName("Prefer access entries"),
+	)
+	SyncIAMUsersField = field.BoolField(
+		"eks-sync-iam-users",
+		field.WithDescription("Sync IAM users, so that grants to IAM users resolve without an AWS connector"),
+		field.WithDisplayName("Sync IAM users"),
+	)
+	ReferencedIAMUsersOnlyField = field.BoolField(
+		"eks-referenced-iam-users-only",
+		field.WithDescription("Only sync the IAM users referenced by aws-auth, access entries or role trust policies"),
+		field.WithDisplayName("Referenced IAM users only"),
+	)
+	RelevantIAMRolesOnlyField = field.BoolField(
+		"eks-relevant-iam-roles-only",
+		field.WithDescription("Only sync the IAM roles mapped by aws-auth or access entries, used by service accounts through IRSA or Pod Identity, or that can assume such a role"),
+		field.WithDisplayName("Relevant IAM roles only"),
+	)
+	IAMRolePathPrefixesField = field.StringSliceField(
+		"eks-iam-role-path-prefixes",
+		field.WithDescription("Only sync the IAM roles whose path starts with one of these prefixes, e.g. /teams/"),
+		field.WithDisplayName("IAM role path prefixes"),
+	)
+	IAMRoleTagsField = field.StringSliceField(
+		"eks-iam-role-tags",
+		field.WithDescription("Only sync the IAM roles that have all of these tags, formatted as key=value or key"),
+		field.WithDisplayName("IAM role tags"),
+	)
+	AccessEntryConcurrencyField = field.IntField(
+		"eks-access-entry-concurrency",
+		field.WithDescription("The number of access entries described concurrently per cluster, lower it if EKS throttles the sync"),
+		field.WithDisplayName("Access entry concurrency"),
+		field.WithDefaultValue(8),
+	)
 	RegionField = field.StringField(
 		"eks-region",
 		field.WithRequired(true),
@@ -79,12 +149,36 @@ var (
 		SecretAccessKeyField,
 		RegionField,
 		ClusterNameField,
+		ClusterNamesField,
+		SyncAllClustersField,
+		DiscoveryRegionsField,
+		ClusterNamePatternsField,
+		ClusterTagsField,
+		RequestableAccessPoliciesField,
+		NonRequestableAccessPoliciesField,
+		PreferAccessEntriesField,
+		SyncIAMUsersField,
+		ReferencedIAMUsersOnlyField,
+		RelevantIAMRolesOnlyField,
+		IAMRolePathPrefixesField,
+		IAMRoleTagsField,
+		AccessEntryConcurrencyField,
 	}
 
 	FieldRelationships = []field.SchemaFieldRelationship{
 		field.FieldsRequiredTogether(AccessKeyIdField, SecretAccessKeyField),
 		field.FieldsMutuallyExclusive(AccessKeyIdField, GlobalAccessKeyIdField),
 		field.FieldsMutuallyExclusive(SecretAccessKeyField, GlobalSecretAccessKeyField),
+		field.FieldsAtLeastOneUsed(ClusterNameField, ClusterNamesField, SyncAllClustersField),
+		field.FieldsMutuallyExclusive(ClusterNameField, ClusterNamesField, SyncAllClustersField),
+		field.FieldsDependentOn(
+			[]field.SchemaField{DiscoveryRegionsField, ClusterNamePatternsField, ClusterTagsField},
+			[]field.SchemaField{SyncAllClustersField},
+		),
+		field.FieldsDependentOn(
+			[]field.SchemaField{ReferencedIAMUsersOnlyField},
+			[]field.SchemaField{SyncIAMUsersField},
+		),
 	}
 )
 
@@ -24,6 +24,109 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid config - multiple clusters",
+			config: &Eks{
+				EksAccessKey:       "MYACCESSKEY01",
+				EksSecretAccessKey: "secretacesskey010203",
+				EksRegion:          "us-east-1",
+				EksClusterNames:    []string{"cluster-a", "cluster-b"},
+				RoleArn:            "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid config - all clusters",
+			config: &Eks{
+				EksAccessKey:       "MYACCESSKEY01",
+				EksSecretAccessKey: "secretacesskey010203",
+				EksRegion:          "us-east-1",
+				EksSyncAllClusters: true,
+				RoleArn:            "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid config - discovery filters",
+			config: &Eks{
+				EksAccessKey:           "MYACCESSKEY01",
+				EksSecretAccessKey:     "secretacesskey010203",
+				EksRegion:              "us-east-1",
+				EksSyncAllClusters:     true,
+				EksDiscoveryRegions:    []string{"us-east-1", "eu-west-1"},
+				EksClusterNamePatterns: []string{"prod-*"},
+				EksClusterTags:         []string{"team=platform"},
+				RoleArn:                "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid config - discovery filters without sync all clusters",
+			config: &Eks{
+				EksRegion:              "us-east-1",
+				EksClusterName:         "my-cluster",
+				EksClusterNamePatterns: []string{"prod-*"},
+				RoleArn:                "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid config - referenced iam users",
+			config: &Eks{
+				EksAccessKey:              "MYACCESSKEY01",
+				EksSecretAccessKey:        "secretacesskey010203",
+				EksRegion:                 "us-east-1",
+				EksClusterName:            "my-cluster",
+				EksSyncIamUsers:           true,
+				EksReferencedIamUsersOnly: true,
+				RoleArn:                   "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid config - iam role filters",
+			config: &Eks{
+				EksAccessKey:            "MYACCESSKEY01",
+				EksSecretAccessKey:      "secretacesskey010203",
+				EksRegion:               "us-east-1",
+				EksClusterName:          "my-cluster",
+				EksRelevantIamRolesOnly: true,
+				EksIamRolePathPrefixes:  []string{"/teams/"},
+				EksIamRoleTags:          []string{"team=platform"},
+				RoleArn:                 "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid config - referenced iam users without syncing iam users",
+			config: &Eks{
+				EksAccessKey:              "MYACCESSKEY01",
+				EksSecretAccessKey:        "secretacesskey010203",
+				EksRegion:                 "us-east-1",
+				EksClusterName:            "my-cluster",
+				EksReferencedIamUsersOnly: true,
+				RoleArn:                   "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - cluster name and cluster names",
+			config: &Eks{
+				EksRegion:       "us-east-1",
+				EksClusterName:  "my-cluster",
+				EksClusterNames: []string{"cluster-a"},
+				RoleArn:         "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid config - no cluster selected",
+			config: &Eks{
+				EksRegion: "us-east-1",
+				RoleArn:   "arn:aws:iam::1234567891012:role/MyRole",
+			},
+			wantErr: true,
+		},
 		{
 			name:    "invalid config - missing required fields",
 			config:  &Eks{},
@@ -0,0 +1,210 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	accessEntryEntitlementPrincipal    = "principal"
+	accessEntryEntitlementAccessPolicy = "access_policy"
+)
+
+// accessEntryBuilder syncs the access entries of EKS clusters as Baton resources.
+type accessEntryBuilder struct {
+	clusters *clusterRegistry
+}
+
+// ResourceType returns the resource type for Access Entries.
+func (a *accessEntryBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return ResourceTypeAccessEntry
+}
+
+// List fetches the access entries of the parent cluster from the EKS API.
+// It runs once per cluster at the start of each sync, and takes the access entry snapshot the other builders read.
+func (a *accessEntryBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	cluster, _, err := a.clusters.lookupParent(parentResourceID)
+	if err != nil || cluster == nil {
+		return nil, "", nil, err
+	}
+
+	snapshot, err := cluster.accessEntries.AccessEntries(ctx, true)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	warnAccessEntryFailures(ctx, cluster, snapshot)
+
+	var rv []*v2.Resource
+	for _, accessEntry := range snapshot.Entries() {
+		resource, err := accessEntryResource(accessEntry)
+		if err != nil {
+			l.Error("failed to create access entry resource",
+				zap.String("principal_arn", accessEntry.PrincipalArn),
+				zap.Error(err))
+			continue
+		}
+		resource = a.clusters.scopeResource(cluster, resource)
+		resource.ParentResourceId = clusterResourceID(cluster)
+		rv = append(rv, resource)
+	}
+
+	return rv, "", nil, nil
+}
+
+// warnAccessEntryFailures logs a warning for each access entry that couldn't be described, or whose access
+// policies couldn't be listed. The sync goes on without failing, but their grants are missing from it.
+func warnAccessEntryFailures(ctx context.Context, cluster *eksCluster, snapshot *client.AccessEntrySnapshot) {
+	l := ctxzap.Extract(ctx)
+	failures := snapshot.Failures()
+	if len(failures) == 0 {
+		return
+	}
+
+	principalARNs := make([]string, 0, len(failures))
+	for principalARN := range failures {
+		principalARNs = append(principalARNs, principalARN)
+	}
+	sort.Strings(principalARNs)
+
+	for _, principalARN := range principalARNs {
+		_, synced := snapshot.Entry(principalARN)
+		l.Warn("failed to describe access entry, its grants are missing from the sync",
+			zap.String("cluster", cluster.id),
+			zap.String("principal_arn", principalARN),
+			zap.Bool("synced_without_access_policies", synced),
+			zap.Error(failures[principalARN]))
+	}
+}
+
+// accessEntryResource creates a Baton resource from an EKS access entry. Its associated access policies are
+// linked to it by grants, see Grants.
+func accessEntryResource(accessEntry *client.AccessEntry) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"access_entry_arn":  accessEntry.AccessEntryArn,
+		"principal_arn":     accessEntry.PrincipalArn,
+		"type":              accessEntry.Type,
+		"username":          accessEntry.Username,
+		"kubernetes_groups": strings.Join(accessEntry.KubernetesGroups, ","),
+		"created_at":        accessEntry.CreatedAt,
+		"modified_at":       accessEntry.ModifiedAt,
+	}
+	if accessEntry.Tags != nil {
+		profile["tags"] = k8s.StringMapToAnyMap(accessEntry.Tags)
+	}
+
+	// The principal ARN identifies the access entry within its cluster.
+	resource, err := rs.NewRoleResource(
+		accessEntry.PrincipalArn,
+		ResourceTypeAccessEntry,
+		accessEntry.PrincipalArn,
+		[]rs.RoleTraitOption{rs.WithRoleProfile(profile)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access entry resource: %w", err)
+	}
+
+	return resource, nil
+}
+
+// Entitlements returns the principal and access policy entitlements of the access entry.
+// Access entries are managed by granting access policies, so the entitlements can't be provisioned.
+func (a *accessEntryBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	principalEnt := entitlement.NewAssignmentEntitlement(
+		resource,
+		accessEntryEntitlementPrincipal,
+		entitlement.WithDisplayName(fmt.Sprintf("%s Access Entry Principal", resource.DisplayName)),
+		entitlement.WithDescription(fmt.Sprintf("The IAM principal of the %s access entry", resource.DisplayName)),
+		entitlement.WithGrantableTo(
+			ResourceTypeIAMUser,
+			ResourceTypeIAMRole,
+		),
+		entitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+	)
+
+	accessPolicyEnt := entitlement.NewAssignmentEntitlement(
+		resource,
+		accessEntryEntitlementAccessPolicy,
+		entitlement.WithDisplayName(fmt.Sprintf("%s Access Entry Access Policy", resource.DisplayName)),
+		entitlement.WithDescription(fmt.Sprintf("The access policies associated with the %s access entry", resource.DisplayName)),
+		entitlement.WithGrantableTo(ResourceTypeAccessPolicy),
+		entitlement.WithAnnotation(&v2.EntitlementImmutable{}),
+	)
+
+	return []*v2.Entitlement{principalEnt, accessPolicyEnt}, "", nil, nil
+}
+
+// Grants links the access entry to its IAM principal, and to the access policies associated with it.
+// The scope of each access policy is recorded as grant metadata.
+func (a *accessEntryBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	cluster, principalARN, err := a.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Grant
+	// Access entries can also belong to principals that aren't synced, such as those of other accounts' services.
+	if client.IsIAMUserARN(principalARN) || client.IsIAMRoleARN(principalARN) {
+		rv = append(rv, processGrants([]string{principalARN}, resource, accessEntryEntitlementPrincipal)...)
+	}
+
+	snapshot, err := cluster.accessEntries.AccessEntries(ctx, false)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to get access entries: %w", err)
+	}
+	accessEntry, ok := snapshot.Entry(principalARN)
+	if !ok {
+		return rv, "", nil, nil
+	}
+	for _, policy := range accessEntry.AssociatedPolicies {
+		if policy.PolicyArn == nil {
+			continue
+		}
+		policyResource := k8s.GenerateResourceForGrant(a.clusters.scopeID(cluster, *policy.PolicyArn), ResourceTypeAccessPolicy.Id)
+		rv = append(rv, grant.NewGrant(
+			resource,
+			accessEntryEntitlementAccessPolicy,
+			policyResource,
+			grant.WithGrantMetadata(accessScopeMetadata(policy.AccessScope)),
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+// accessScopeMetadata describes the scope of an associated access policy: the cluster, or the namespaces.
+func accessScopeMetadata(scope *eksTypes.AccessScope) map[string]interface{} {
+	if scope == nil || scope.Type != eksTypes.AccessScopeTypeNamespace {
+		return map[string]interface{}{"scope": string(eksTypes.AccessScopeTypeCluster)}
+	}
+	namespaces := make([]interface{}, 0, len(scope.Namespaces))
+	for _, namespace := range scope.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	return map[string]interface{}{
+		"scope":      string(eksTypes.AccessScopeTypeNamespace),
+		"namespaces": namespaces,
+	}
+}
+
+// NewAccessEntryBuilder creates a new access entry builder.
+func NewAccessEntryBuilder(clusters *clusterRegistry) *accessEntryBuilder {
+	return &accessEntryBuilder{
+		clusters: clusters,
+	}
+}
@@ -0,0 +1,146 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/conductorone/baton-eks/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAccessEntryClient implements the AccessEntryClient interface for testing.
+type mockAccessEntryClient struct {
+	entries map[string]*client.AccessEntry
+	// accessErr is returned by CheckAccessEntryAccess.
+	accessErr error
+}
+
+func (m *mockAccessEntryClient) AccessEntries(ctx context.Context, refresh bool) (*client.AccessEntrySnapshot, error) {
+	var entries []*client.AccessEntry
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	return client.NewAccessEntrySnapshot(entries), nil
+}
+
+func (m *mockAccessEntryClient) DescribeAccessEntry(ctx context.Context, principalARN string) (*client.AccessEntry, error) {
+	entry, ok := m.entries[principalARN]
+	if !ok {
+		return nil, errors.New("access entry not found")
+	}
+	return entry, nil
+}
+
+func (m *mockAccessEntryClient) CheckAccessEntryAccess(ctx context.Context) error {
+	return m.accessErr
+}
+
+func newTestAccessEntryRegistry(scoped bool) *clusterRegistry {
+	return newTestClusterRegistry(scoped,
+		withAccessEntries(&mockAccessEntryClient{
+			entries: map[string]*client.AccessEntry{
+				"arn:aws:iam::123456789012:role/admin": {
+					AccessEntryArn:   "arn:aws:eks:us-east-1:123456789012:access-entry/test-cluster/role/123456789012/admin/abc",
+					PrincipalArn:     "arn:aws:iam::123456789012:role/admin",
+					Type:             "STANDARD",
+					Username:         "arn:aws:sts::123456789012:assumed-role/admin/{{SessionName}}",
+					KubernetesGroups: []string{"admins", "viewers"},
+					Tags:             map[string]string{"team": "platform"},
+					AssociatedPolicies: []eksTypes.AssociatedAccessPolicy{
+						{
+							PolicyArn:   aws.String("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy"),
+							AccessScope: &eksTypes.AccessScope{Type: eksTypes.AccessScopeTypeNamespace, Namespaces: []string{"dev", "prod"}},
+						},
+						{
+							PolicyArn:   aws.String("arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy"),
+							AccessScope: &eksTypes.AccessScope{Type: eksTypes.AccessScopeTypeCluster},
+						},
+					},
+				},
+			},
+		}),
+	)
+}
+
+func TestAccessEntryBuilder_List(t *testing.T) {
+	registry := newTestAccessEntryRegistry(true)
+	builder := NewAccessEntryBuilder(registry)
+	cluster := registry.all()[0]
+
+	t.Run("without parent", func(t *testing.T) {
+		resources, _, _, err := builder.List(context.Background(), nil, &pagination.Token{})
+		require.NoError(t, err)
+		assert.Empty(t, resources)
+	})
+
+	resources, nextToken, _, err := builder.List(context.Background(), clusterResourceID(cluster), &pagination.Token{})
+	require.NoError(t, err)
+	assert.Empty(t, nextToken)
+	require.Len(t, resources, 1)
+
+	resource := resources[0]
+	assert.Equal(t, "us-east-1/test-cluster/arn:aws:iam::123456789012:role/admin", resource.Id.Resource)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/admin", resource.DisplayName)
+	assert.Equal(t, clusterResourceID(cluster), resource.ParentResourceId)
+
+	roleTrait := &v2.RoleTrait{}
+	annos := annotations.Annotations(resource.Annotations)
+	ok, err := annos.Pick(roleTrait)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	fields := roleTrait.GetProfile().GetFields()
+	assert.Equal(t, "STANDARD", fields["type"].GetStringValue())
+	assert.Equal(t, "admins,viewers", fields["kubernetes_groups"].GetStringValue())
+	assert.Equal(t, "platform", fields["tags"].GetStructValue().GetFields()["team"].GetStringValue())
+	// Associated access policies are granted to the entry by the access policies.
+	assert.NotContains(t, fields, "access_policies")
+}
+
+func TestAccessEntryBuilder_Grants(t *testing.T) {
+	registry := newTestAccessEntryRegistry(true)
+	builder := NewAccessEntryBuilder(registry)
+	cluster := registry.all()[0]
+
+	resources, _, _, err := builder.List(context.Background(), clusterResourceID(cluster), &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+
+	entitlements, _, _, err := builder.Entitlements(context.Background(), resources[0], &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, entitlements, 2)
+	assert.Equal(t, accessEntryEntitlementPrincipal, entitlements[0].Slug)
+	assert.Equal(t, accessEntryEntitlementAccessPolicy, entitlements[1].Slug)
+
+	grants, _, _, err := builder.Grants(context.Background(), resources[0], &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, grants, 3)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/admin", grants[0].Principal.Id.Resource)
+	assert.Equal(t, ResourceTypeIAMRole.Id, grants[0].Principal.Id.ResourceType)
+
+	// The associated access policies are granted the access policy entitlement, with their scope.
+	var policies []string
+	for _, g := range grants[1:] {
+		assert.Equal(t, ResourceTypeAccessPolicy.Id, g.Principal.Id.ResourceType)
+		assert.Equal(t, entitlements[1].Id, g.Entitlement.Id)
+
+		metadata := &v2.GrantMetadata{}
+		annos := annotations.Annotations(g.Annotations)
+		ok, err := annos.Pick(metadata)
+		require.NoError(t, err)
+		require.True(t, ok)
+		policies = append(policies, fmt.Sprintf("%s %v", g.Principal.Id.Resource, metadata.Metadata.AsMap()))
+	}
+	assert.Equal(t, []string{
+		"us-east-1/test-cluster/arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy map[namespaces:[dev prod] scope:namespace]",
+		"us-east-1/test-cluster/arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy map[scope:cluster]",
+	}, policies)
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
 	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/conductorone/baton-eks/pkg/client"
 	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
@@ -23,12 +22,54 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 )
 
-const eksAccessPolicyARNPrefix = "arn:aws:eks::aws:cluster-access-policy/"
+// eksAccessPolicyARN returns the ARN of a standard access policy in the partition of a cluster.
+func eksAccessPolicyARN(partition string, name string) string {
+	return fmt.Sprintf("arn:%s:eks::aws:cluster-access-policy/%s", partition, name)
+}
 
 // accessPolicyBuilder syncs EKS Access Policies as Baton resources.
 type accessPolicyBuilder struct {
-	eksClient    client.AccessPolicyClient
+	clusters     *clusterRegistry
 	resourceType *v2.ResourceType
+	requestable  *accessPolicyFilter
+}
+
+// accessPolicyFilter decides which access policies can be requested, matching policies by name or ARN.
+// Policies in the denylist are never requestable. When the allowlist is set, only its policies are.
+type accessPolicyFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+func newAccessPolicyFilter(allow []string, deny []string) *accessPolicyFilter {
+	toSet := func(values []string) map[string]bool {
+		set := make(map[string]bool, len(values))
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				set[v] = true
+			}
+		}
+		return set
+	}
+	return &accessPolicyFilter{
+		allow: toSet(allow),
+		deny:  toSet(deny),
+	}
+}
+
+// isRequestable reports whether grants of the policy can be requested. A nil filter allows every policy.
+func (f *accessPolicyFilter) isRequestable(policyARN string) bool {
+	if f == nil {
+		return true
+	}
+	name := policyARN[strings.LastIndex(policyARN, "/")+1:]
+	if f.deny[name] || f.deny[policyARN] {
+		return false
+	}
+	if len(f.allow) > 0 {
+		return f.allow[name] || f.allow[policyARN]
+	}
+	return true
 }
 
 // ResourceType returns the resource type for Access Policies.
@@ -36,15 +77,26 @@ func (a *accessPolicyBuilder) ResourceType(ctx context.Context) *v2.ResourceType
 	return a.resourceType
 }
 
-// List fetches all Access Policies from the EKS API.
-func (a *accessPolicyBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+// List fetches the Access Policies available in the parent cluster.
+func (a *accessPolicyBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
+	cluster, _, err := a.clusters.lookupParent(parentResourceID)
+	if err != nil || cluster == nil {
+		return nil, "", nil, err
+	}
+
 	// Initialize empty resource slice.
 	var rv []*v2.Resource
 
-	// Get all standard EKS Access Policies
-	policies := a.getStandardPolicies()
+	// Get every Access Policy offered by EKS, falling back to the standard policies if they can't be listed.
+	policies, err := cluster.accessPolicies.ListAccessPolicies(ctx)
+	if err != nil {
+		l.Warn("failed to list access policies, using the standard access policies", zap.Error(err))
+		policies = a.getStandardPolicies(cluster.partition())
+	} else {
+		a.enrichPolicies(policies)
+	}
 
 	// Process policies for current page
 	for _, policy := range policies {
@@ -55,12 +107,27 @@ func (a *accessPolicyBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pag
 				zap.Error(err))
 			continue
 		}
+		resource = a.clusters.scopeResource(cluster, resource)
+		resource.ParentResourceId = clusterResourceID(cluster)
 		rv = append(rv, resource)
 	}
 
 	return rv, "", nil, nil
 }
 
+// enrichPolicies adds the curated descriptions of the standard policies.
+func (a *accessPolicyBuilder) enrichPolicies(policies []*client.AccessPolicy) {
+	descriptions := make(map[string]string)
+	for _, policy := range a.getStandardPolicies(client.DefaultPartition) {
+		descriptions[policy.DisplayName] = policy.Description
+	}
+	for _, policy := range policies {
+		if policy.Description == "" {
+			policy.Description = descriptions[policy.DisplayName]
+		}
+	}
+}
+
 // policyResource creates a Baton resource from an EKS Access Policy.
 func (a *accessPolicyBuilder) policyResource(policy *client.AccessPolicy) (*v2.Resource, error) {
 	// Prepare profile with policy metadata
@@ -98,7 +165,20 @@ func (a *accessPolicyBuilder) Entitlements(ctx context.Context, resource *v2.Res
 		return nil, "", nil, fmt.Errorf("failed to parse page token: %w", err)
 	}
 	opts.Continue = bag.PageToken()
-	namespaces, err := a.eksClient.ListNamespaces(ctx, opts)
+	cluster, policyARN, err := a.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	entOpts := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(
+			ResourceTypeIAMUser,
+			ResourceTypeIAMRole,
+		),
+	}
+	if !a.requestable.isRequestable(policyARN) {
+		entOpts = append(entOpts, entitlement.WithAnnotation(&v2.EntitlementImmutable{}))
+	}
+	namespaces, err := cluster.accessPolicies.ListNamespaces(ctx, opts)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
@@ -108,12 +188,10 @@ func (a *accessPolicyBuilder) Entitlements(ctx context.Context, resource *v2.Res
 		nsEnt := entitlement.NewAssignmentEntitlement(
 			resource,
 			entitlementName,
-			entitlement.WithDisplayName(fmt.Sprintf("%s access policy scoped to '%s' namespace", resource.DisplayName, ns.Name)),
-			entitlement.WithDescription(fmt.Sprintf("Grants assignment to the %s access policy in namespace '%s'.", resource.DisplayName, ns.Name)),
-			entitlement.WithGrantableTo(
-				ResourceTypeIAMUser,
-				ResourceTypeIAMRole,
-			),
+			append([]entitlement.EntitlementOption{
+				entitlement.WithDisplayName(fmt.Sprintf("%s access policy scoped to '%s' namespace", resource.DisplayName, ns.Name)),
+				entitlement.WithDescription(fmt.Sprintf("Grants assignment to the %s access policy in namespace '%s'.", resource.DisplayName, ns.Name)),
+			}, entOpts...)...,
 		)
 		entitlements = append(entitlements, nsEnt)
 	}
@@ -129,86 +207,59 @@ func (a *accessPolicyBuilder) Entitlements(ctx context.Context, resource *v2.Res
 	assignedEnt := entitlement.NewAssignmentEntitlement(
 		resource,
 		"assigned:cluster",
-		entitlement.WithDisplayName(fmt.Sprintf("%s access policy (cluster scope)", resource.DisplayName)),
-		entitlement.WithDescription(fmt.Sprintf("Grants assignment to the %s access policy for the entire cluster.", resource.DisplayName)),
-		entitlement.WithGrantableTo(
-			ResourceTypeIAMUser,
-			ResourceTypeIAMRole,
-		),
+		append([]entitlement.EntitlementOption{
+			entitlement.WithDisplayName(fmt.Sprintf("%s access policy (cluster scope)", resource.DisplayName)),
+			entitlement.WithDescription(fmt.Sprintf("Grants assignment to the %s access policy for the entire cluster.", resource.DisplayName)),
+		}, entOpts...)...,
 	)
 	entitlements = append(entitlements, assignedEnt)
 
 	return entitlements, "", nil, nil
 }
 
-// Grants returns permission grants for Access Policy resources.
-func (a *accessPolicyBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+// Grants returns permission grants for Access Policy resources, read from the access entry snapshot of the cluster.
+func (a *accessPolicyBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 	var rv []*v2.Grant
 
-	policyARN := resource.Id.Resource
-	bag, err := k8s.ParsePageToken(pToken.Token)
+	cluster, policyARN, err := a.clusters.lookup(resource.Id.Resource)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to parse page token: %w", err)
-	}
-
-	var eksNextToken *string
-	if bag.PageToken() != "" {
-		eksNextToken = aws.String(bag.PageToken())
+		return nil, "", nil, err
 	}
 
-	principalARNs, eksNextPageToken, err := a.eksClient.GetAccessEntriesWithPolicy(ctx, policyARN, eksNextToken)
+	snapshot, err := cluster.accessPolicies.AccessEntries(ctx, false)
 	if err != nil {
 		l.Error("failed to get access entries", zap.Error(err))
 		return nil, "", nil, fmt.Errorf("failed to get access entries: %w", err)
 	}
 
-	// Create grants for principals in this page
-	for _, principalARN := range principalARNs {
-		// Get the scope information for this specific policy
-		policyScope, err := a.getPolicyScope(ctx, principalARN, policyARN)
-		if err != nil {
-			l.Error("failed to get policy scope", zap.Error(err))
-			continue // Skip this principal if we can't get scope info
-		}
-		if policyScope == nil {
+	for _, accessEntry := range snapshot.Entries() {
+		policyScope, ok := accessEntry.PolicyScope(policyARN)
+		if !ok {
 			// Policy is not associated to the principal
 			continue
 		}
 
 		// Create grants based on scope
-		grants := a.createGrantsForPrincipal(resource, principalARN, policyScope)
+		grants := a.createGrantsForPrincipal(resource, accessEntry.PrincipalArn, policyScope)
 		rv = append(rv, grants...)
 	}
 
-	var nextPageToken string
-	if eksNextPageToken != nil && *eksNextPageToken != "" {
-		bag.Push(pagination.PageState{Token: *eksNextPageToken})
-		token, err := bag.Marshal()
-		if err != nil {
-			return nil, "", nil, fmt.Errorf("failed to marshal pagination bag: %w", err)
-		}
-		nextPageToken = token
-	}
-
-	return rv, nextPageToken, nil, nil
+	return rv, "", nil, nil
 }
 
-// getPolicyScope retrieves the scope information for a specific policy.
-func (a *accessPolicyBuilder) getPolicyScope(ctx context.Context, principalARN, policyARN string) (*eksTypes.AccessScope, error) {
-	associatedPolicies, err := a.eksClient.GetAssociatedAccessPolicies(ctx, principalARN)
+// getPolicyScope retrieves the current scope of a specific policy. Provisioning reads it from EKS
+// rather than from the access entry snapshot, which may be stale.
+func (a *accessPolicyBuilder) getPolicyScope(ctx context.Context, cluster *eksCluster, principalARN, policyARN string) (*eksTypes.AccessScope, error) {
+	associatedPolicies, err := cluster.accessPolicies.GetAssociatedAccessPolicies(ctx, principalARN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get associated access policies: %w", err)
 	}
 
 	// Find the specific policy we're looking for
-	for _, policy := range associatedPolicies {
-		if policy.PolicyArn != nil && *policy.PolicyArn == policyARN {
-			return policy.AccessScope, nil
-		}
-	}
-
-	return nil, nil
+	accessEntry := &client.AccessEntry{AssociatedPolicies: associatedPolicies}
+	policyScope, _ := accessEntry.PolicyScope(policyARN)
+	return policyScope, nil
 }
 
 // createGrantsForPrincipal creates grants based on the policy scope.
@@ -219,7 +270,7 @@ func (a *accessPolicyBuilder) createGrantsForPrincipal(resource *v2.Resource, pr
 	// Determine principal type and resource type
 	var principalResource *v2.Resource
 	var resourceType string
-	if strings.Contains(principalARN, ":role/") {
+	if client.IsIAMRoleARN(principalARN) {
 		principalResource = k8s.GenerateResourceForGrant(principalARN, ResourceTypeIAMRole.Id)
 		resourceType = ResourceTypeIAMRole.Id
 	} else {
@@ -228,16 +279,8 @@ func (a *accessPolicyBuilder) createGrantsForPrincipal(resource *v2.Resource, pr
 	}
 
 	// Create grants based on scope
-	if scope.Type == "namespace" && len(scope.Namespaces) > 0 {
-		// Create a grant for each namespace
-		for _, namespace := range scope.Namespaces {
-			entitlementName := fmt.Sprintf("assigned:%s", namespace)
-			grant := a.createGrant(resource, principalResource, entitlementName, principalARN, resourceType)
-			grants = append(grants, grant)
-		}
-	} else {
-		// Cluster scope - single grant
-		grant := a.createGrant(resource, principalResource, "assigned:cluster", principalARN, resourceType)
+	for _, entitlementName := range policyScopeEntitlements(scope) {
+		grant := a.createGrant(resource, principalResource, entitlementName, principalARN, resourceType)
 		grants = append(grants, grant)
 	}
 
@@ -251,6 +294,19 @@ func (a *accessPolicyBuilder) createGrantsForPrincipal(resource *v2.Resource, pr
 	return grants
 }
 
+// policyScopeEntitlements returns the entitlements of an access policy scope: one for each namespace of a
+// namespace scope, or the cluster entitlement.
+func policyScopeEntitlements(scope *eksTypes.AccessScope) []string {
+	if scope.Type != "namespace" || len(scope.Namespaces) == 0 {
+		return []string{"assigned:cluster"}
+	}
+	entitlementNames := make([]string, 0, len(scope.Namespaces))
+	for _, namespace := range scope.Namespaces {
+		entitlementNames = append(entitlementNames, fmt.Sprintf("assigned:%s", namespace))
+	}
+	return entitlementNames
+}
+
 // createGrant creates a single grant with appropriate options.
 func (a *accessPolicyBuilder) createGrant(
 	resource *v2.Resource,
@@ -305,18 +361,24 @@ func (a *accessPolicyBuilder) parseEntitlementScope(entitlementName string) *eks
 
 func (a *accessPolicyBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
-	if principal.Id.ResourceType != ResourceTypeIAMUser.Id {
-		return nil, fmt.Errorf("principal is not an IAM user")
+	principalARN, err := iamPrincipalARN(principal.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	principalARN := principal.Id.Resource
-	policyARN := entitlement.Resource.Id.Resource
+	cluster, policyARN, err := a.clusters.lookup(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if !a.requestable.isRequestable(policyARN) {
+		return nil, fmt.Errorf("access policy %s is not requestable", policyARN)
+	}
 
 	// Parse the scope from the entitlement name
 	accessScope := a.parseEntitlementScope(entitlement.Id)
 
 	// Create access entry if it does not exist
-	_, err := a.eksClient.CreateAccessEntry(ctx, principalARN)
+	_, err = cluster.accessPolicies.CreateAccessEntry(ctx, principalARN)
 	if err != nil {
 		if !isAccessEntryAlreadyExistsError(err) {
 			return nil, fmt.Errorf("failed to create access entry: %w", err)
@@ -326,16 +388,16 @@ func (a *accessPolicyBuilder) Grant(ctx context.Context, principal *v2.Resource,
 	// If scope is namespace, we fetch the policy associated and update it adding the namespace,
 	// otherwise we would be disassociating the policy from existing namespaces.
 	if accessScope.Type == eksTypes.AccessScopeTypeNamespace {
-		policyScope, err := a.getPolicyScope(ctx, principalARN, policyARN)
+		policyScope, err := a.getPolicyScope(ctx, cluster, principalARN, policyARN)
 		if err != nil {
 			l.Error("failed to get policy scope", zap.Error(err))
 			return nil, fmt.Errorf("failed to get policy scope: %w", err)
 		}
 		if policyScope != nil {
 			if policyScope.Type == eksTypes.AccessScopeTypeCluster {
-				// Trying to grant a namespace scoped policy, but user already has a cluster scoped policy.
+				// Trying to grant a namespace scoped policy, but principal already has a cluster scoped policy.
 				// Scoping the policy to the namespace would disassociate the policy from the cluster, affecting other namespaces.
-				return nil, fmt.Errorf("try to grant a namespace scoped policy, but user already has a cluster scoped policy")
+				return nil, fmt.Errorf("try to grant a namespace scoped policy, but principal already has a cluster scoped policy")
 			}
 			if policyScope.Type == eksTypes.AccessScopeTypeNamespace {
 				// Verify if the namespace is already in the policy scope
@@ -351,7 +413,7 @@ func (a *accessPolicyBuilder) Grant(ctx context.Context, principal *v2.Resource,
 		}
 	}
 	// Associate the policy with the specified scope
-	err = a.eksClient.AssociateAccessPolicy(ctx, principalARN, policyARN, accessScope)
+	err = cluster.accessPolicies.AssociateAccessPolicy(ctx, principalARN, policyARN, accessScope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create policy association: %w", err)
 	}
@@ -359,6 +421,17 @@ func (a *accessPolicyBuilder) Grant(ctx context.Context, principal *v2.Resource,
 	return nil, nil
 }
 
+// iamPrincipalARN returns the ARN of the IAM user or role an entitlement is granted to.
+// The principal ARN is the resource ID of both IAM users and roles.
+func iamPrincipalARN(principal *v2.ResourceId) (string, error) {
+	switch principal.ResourceType {
+	case ResourceTypeIAMUser.Id, ResourceTypeIAMRole.Id:
+		return principal.Resource, nil
+	default:
+		return "", fmt.Errorf("principal must be an IAM user or role, got %s", principal.ResourceType)
+	}
+}
+
 func isAccessEntryAlreadyExistsError(err error) bool {
 	var resourceInUseErr *eksTypes.ResourceInUseException
 	var conflictErr *eksTypes.ClientException
@@ -371,16 +444,19 @@ func isAccessEntryAlreadyExistsError(err error) bool {
 
 func (a *accessPolicyBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
-	if grant.Principal.Id.ResourceType != ResourceTypeIAMUser.Id {
-		return nil, fmt.Errorf("principal is not an IAM user")
+	principalARN, err := iamPrincipalARN(grant.Principal.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	policyARN := grant.Entitlement.Resource.Id.Resource
-	principalARN := grant.Principal.Id.Resource
+	cluster, policyARN, err := a.clusters.lookup(grant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
 
 	accessScope := a.parseEntitlementScope(grant.Entitlement.Id)
 	if accessScope.Type == eksTypes.AccessScopeTypeNamespace {
-		policyScope, err := a.getPolicyScope(ctx, principalARN, policyARN)
+		policyScope, err := a.getPolicyScope(ctx, cluster, principalARN, policyARN)
 		if err != nil {
 			l.Error("failed to get policy scope", zap.Error(err))
 			return nil, fmt.Errorf("failed to get policy scope: %w", err)
@@ -390,9 +466,9 @@ func (a *accessPolicyBuilder) Revoke(ctx context.Context, grant *v2.Grant) (anno
 			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
 		}
 		if policyScope.Type == eksTypes.AccessScopeTypeCluster {
-			// Trying to revoke a namespace scoped policy, but user already has a cluster scoped policy.
+			// Trying to revoke a namespace scoped policy, but principal already has a cluster scoped policy.
 			// Revoking the policy from the namespace would disassociate the policy from the cluster, affecting other namespaces.
-			return nil, fmt.Errorf("trying to revoke a namespace scoped policy, but user already has a cluster scoped policy")
+			return nil, fmt.Errorf("trying to revoke a namespace scoped policy, but principal already has a cluster scoped policy")
 		}
 		// Type == Namespace
 		containsNamespace := false
@@ -418,7 +494,7 @@ func (a *accessPolicyBuilder) Revoke(ctx context.Context, grant *v2.Grant) (anno
 				Type:       eksTypes.AccessScopeTypeNamespace,
 				Namespaces: remainingNamespaces,
 			}
-			err = a.eksClient.AssociateAccessPolicy(ctx, principalARN, policyARN, updatedScope)
+			err = cluster.accessPolicies.AssociateAccessPolicy(ctx, principalARN, policyARN, updatedScope)
 			if err != nil {
 				return nil, fmt.Errorf("failed to update policy association: %w", err)
 			}
@@ -426,7 +502,7 @@ func (a *accessPolicyBuilder) Revoke(ctx context.Context, grant *v2.Grant) (anno
 		}
 	}
 
-	err := a.eksClient.DisassociateAccessPolicy(ctx, principalARN, policyARN)
+	err = cluster.accessPolicies.DisassociateAccessPolicy(ctx, principalARN, policyARN)
 	if err != nil {
 		if isAccessPolicyAssociationNotFoundError(err) {
 			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
@@ -444,38 +520,38 @@ func isAccessPolicyAssociationNotFoundError(err error) bool {
 	return errors.As(err, &resourceNotFoundErr)
 }
 
-// getStandardPolicies returns all standard EKS Access Policies.
+// getStandardPolicies returns all standard EKS Access Policies of the partition.
 // Access policies are defined by EKS and cannot be created or modified by the user.
 // https://docs.aws.amazon.com/eks/latest/userguide/access-policy-permissions.html.
-func (a *accessPolicyBuilder) getStandardPolicies() []*client.AccessPolicy {
+func (a *accessPolicyBuilder) getStandardPolicies(partition string) []*client.AccessPolicy {
 	// The following list is limited to policies that are related (assignable) to users/roles.
 	return []*client.AccessPolicy{
 		{
-			PolicyARN:   eksAccessPolicyARNPrefix + "AmazonEKSClusterAdminPolicy",
+			PolicyARN:   eksAccessPolicyARN(partition, "AmazonEKSClusterAdminPolicy"),
 			DisplayName: "AmazonEKSClusterAdminPolicy",
 			Description: "This access policy includes permissions that grant an IAM principal administrator access to a cluster. " +
 				"When associated to an access entry, its access scope is typically the cluster, rather than a Kubernetes namespace. " +
 				"If you want an IAM principal to have a more limited administrative scope, consider associating the AmazonEKSAdminPolicy access policy to your access entry instead.",
 		},
 		{
-			PolicyARN:   eksAccessPolicyARNPrefix + "AmazonEKSAdminPolicy",
+			PolicyARN:   eksAccessPolicyARN(partition, "AmazonEKSAdminPolicy"),
 			DisplayName: "AmazonEKSAdminPolicy",
 			Description: "This access policy includes permissions that grant an IAM principal most permissions to resources. " +
 				"When associated to an access entry, its access scope is typically one or more Kubernetes namespaces. " +
 				"If you want an IAM principal to have administrator access to all resources on your cluster, associate the AmazonEKSClusterAdminPolicy access policy to your access entry instead.",
 		},
 		{
-			PolicyARN:   eksAccessPolicyARNPrefix + "AmazonEKSViewPolicy",
+			PolicyARN:   eksAccessPolicyARN(partition, "AmazonEKSViewPolicy"),
 			DisplayName: "AmazonEKSViewPolicy",
 			Description: "This access policy includes permissions that allow an IAM principal to view most Kubernetes resources.",
 		},
 		{
-			PolicyARN:   eksAccessPolicyARNPrefix + "AmazonEKSEditPolicy",
+			PolicyARN:   eksAccessPolicyARN(partition, "AmazonEKSEditPolicy"),
 			DisplayName: "AmazonEKSEditPolicy",
 			Description: "This access policy includes permissions that allow an IAM principal to edit most Kubernetes resources.",
 		},
 		{
-			PolicyARN:   eksAccessPolicyARNPrefix + "AmazonEKSAdminViewPolicy",
+			PolicyARN:   eksAccessPolicyARN(partition, "AmazonEKSAdminViewPolicy"),
 			DisplayName: "AmazonEKSAdminViewPolicy",
 			Description: "This access policy includes permissions that grant an IAM principal access to list/view all resources in a cluster. Note this includes Kubernetes Secrets.",
 		},
@@ -483,9 +559,10 @@ func (a *accessPolicyBuilder) getStandardPolicies() []*client.AccessPolicy {
 }
 
 // NewPolicyBuilder creates a new policy builder.
-func NewAccessPolicyBuilder(eksClient client.AccessPolicyClient) *accessPolicyBuilder {
+func NewAccessPolicyBuilder(clusters *clusterRegistry, requestable *accessPolicyFilter) *accessPolicyBuilder {
 	return &accessPolicyBuilder{
-		eksClient:    eksClient,
+		clusters:     clusters,
 		resourceType: ResourceTypeAccessPolicy,
+		requestable:  requestable,
 	}
 }
@@ -4,9 +4,14 @@ import (
 	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/conductorone/baton-eks/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -26,8 +31,23 @@ func (m *mockAccessPolicyClient) ListNamespaces(ctx context.Context, opts metav1
 	}, nil
 }
 
-func (m *mockAccessPolicyClient) GetAccessEntriesWithPolicy(ctx context.Context, policyARN string, nextToken *string) ([]string, *string, error) {
-	return []string{"arn:aws:iam::123456789012:user/testuser"}, nil, nil
+func (m *mockAccessPolicyClient) ListAccessPolicies(ctx context.Context) ([]*client.AccessPolicy, error) {
+	return []*client.AccessPolicy{
+		{
+			PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy",
+			DisplayName: "AmazonEKSClusterAdminPolicy",
+		},
+		{
+			PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSAutoNodePolicy",
+			DisplayName: "AmazonEKSAutoNodePolicy",
+		},
+	}, nil
+}
+
+func (m *mockAccessPolicyClient) AccessEntries(ctx context.Context, refresh bool) (*client.AccessEntrySnapshot, error) {
+	return client.NewAccessEntrySnapshot([]*client.AccessEntry{
+		{PrincipalArn: "arn:aws:iam::123456789012:user/testuser", Type: "STANDARD"},
+	}), nil
 }
 
 func (m *mockAccessPolicyClient) GetAssociatedAccessPolicies(ctx context.Context, principalARN string) ([]eksTypes.AssociatedAccessPolicy, error) {
@@ -53,7 +73,7 @@ func TestPolicyBuilder_ResourceType(t *testing.T) {
 	eksClient := &mockAccessPolicyClient{}
 
 	// Create policy builder
-	builder := NewAccessPolicyBuilder(eksClient)
+	builder := NewAccessPolicyBuilder(newTestClusterRegistry(false, withAccessPolicies(eksClient)), nil)
 
 	// Test resource type
 	resourceType := builder.ResourceType(context.Background())
@@ -67,7 +87,7 @@ func TestPolicyBuilder_Entitlements(t *testing.T) {
 	eksClient := &mockAccessPolicyClient{}
 
 	// Create policy builder
-	builder := NewAccessPolicyBuilder(eksClient)
+	builder := NewAccessPolicyBuilder(newTestClusterRegistry(false, withAccessPolicies(eksClient)), nil)
 
 	// Create a test resource
 	resource, err := builder.policyResource(&client.AccessPolicy{
@@ -95,12 +115,98 @@ func TestPolicyBuilder_Entitlements(t *testing.T) {
 	assert.Contains(t, entitlementIDs, expectedClusterId)
 }
 
+func TestPolicyBuilder_EntitlementsScopedByCluster(t *testing.T) {
+	registry := newTestClusterRegistry(true, withAccessPolicies(&mockAccessPolicyClient{}))
+	builder := NewAccessPolicyBuilder(registry, nil)
+
+	// List scopes the policy ARN by the cluster ID.
+	resources, _, _, err := builder.List(context.Background(), clusterResourceID(registry.all()[0]), &pagination.Token{})
+	assert.NoError(t, err)
+	assert.Len(t, resources, 2)
+
+	entitlements, _, _, err := builder.Entitlements(context.Background(), resources[0], nil)
+	assert.NoError(t, err)
+	entitlementIDs := make([]string, len(entitlements))
+	for i, ent := range entitlements {
+		entitlementIDs[i] = ent.Id
+	}
+
+	expectedID := "access_policy:us-east-1/test-cluster/arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy:assigned:default"
+	assert.Contains(t, entitlementIDs, expectedID)
+	assert.Equal(t, "default", builder.parseEntitlementScope(expectedID).Namespaces[0])
+}
+
+func TestPolicyBuilder_ListAccessPolicies(t *testing.T) {
+	registry := newTestClusterRegistry(false, withAccessPolicies(&mockAccessPolicyClient{}))
+	builder := NewAccessPolicyBuilder(registry, nil)
+
+	resources, _, _, err := builder.List(context.Background(), clusterResourceID(registry.all()[0]), &pagination.Token{})
+	assert.NoError(t, err)
+	assert.Len(t, resources, 2)
+
+	descriptions := make(map[string]string)
+	for _, resource := range resources {
+		roleTrait := &v2.RoleTrait{}
+		annos := annotations.Annotations(resource.Annotations)
+		ok, err := annos.Pick(roleTrait)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		descriptions[resource.DisplayName] = roleTrait.Profile.AsMap()["description"].(string)
+	}
+
+	// Standard policies keep their curated description, newer policies are synced without one.
+	assert.Contains(t, descriptions["AmazonEKSClusterAdminPolicy"], "administrator access to a cluster")
+	assert.Empty(t, descriptions["AmazonEKSAutoNodePolicy"])
+}
+
+func TestAccessPolicyFilter(t *testing.T) {
+	const (
+		adminARN = "arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy"
+		viewARN  = "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy"
+		editARN  = "arn:aws:eks::aws:cluster-access-policy/AmazonEKSEditPolicy"
+	)
+
+	var noFilter *accessPolicyFilter
+	assert.True(t, noFilter.isRequestable(adminARN))
+
+	denied := newAccessPolicyFilter(nil, []string{"AmazonEKSClusterAdminPolicy"})
+	assert.False(t, denied.isRequestable(adminARN))
+	assert.True(t, denied.isRequestable(viewARN))
+
+	allowed := newAccessPolicyFilter([]string{viewARN, "AmazonEKSEditPolicy"}, []string{"AmazonEKSEditPolicy"})
+	assert.True(t, allowed.isRequestable(viewARN))
+	assert.False(t, allowed.isRequestable(editARN))
+	assert.False(t, allowed.isRequestable(adminARN))
+}
+
+func TestPolicyBuilder_NonRequestablePolicy(t *testing.T) {
+	registry := newTestClusterRegistry(false, withAccessPolicies(&mockAccessPolicyClient{}))
+	builder := NewAccessPolicyBuilder(registry, newAccessPolicyFilter(nil, []string{"AmazonEKSClusterAdminPolicy"}))
+
+	resource, err := builder.policyResource(&client.AccessPolicy{
+		PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy",
+		DisplayName: "AmazonEKSClusterAdminPolicy",
+	})
+	assert.NoError(t, err)
+
+	entitlements, _, _, err := builder.Entitlements(context.Background(), resource, nil)
+	assert.NoError(t, err)
+	for _, ent := range entitlements {
+		annos := annotations.Annotations(ent.Annotations)
+		assert.True(t, annos.Contains(&v2.EntitlementImmutable{}))
+	}
+
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: ResourceTypeIAMUser.Id, Resource: "arn:aws:iam::123456789012:user/testuser"}}
+	_, err = builder.Grant(context.Background(), principal, entitlements[0])
+	assert.Error(t, err)
+}
+
 func TestPolicyBuilder_PolicyResource(t *testing.T) {
 	// Create a mock EKS client
 	eksClient := &mockAccessPolicyClient{}
 
 	// Create policy builder
-	builder := NewAccessPolicyBuilder(eksClient)
+	builder := NewAccessPolicyBuilder(newTestClusterRegistry(false, withAccessPolicies(eksClient)), nil)
 
 	// Test policy resource creation
 	policy := &client.AccessPolicy{
@@ -122,10 +228,10 @@ func TestPolicyBuilder_GetStandardPolicies(t *testing.T) {
 	eksClient := &mockAccessPolicyClient{}
 
 	// Create policy builder
-	builder := NewAccessPolicyBuilder(eksClient)
+	builder := NewAccessPolicyBuilder(newTestClusterRegistry(false, withAccessPolicies(eksClient)), nil)
 
 	// Test getStandardPolicies
-	policies := builder.getStandardPolicies()
+	policies := builder.getStandardPolicies(client.DefaultPartition)
 
 	// Should have 5 standard policies
 	assert.Len(t, policies, 5)
@@ -147,4 +253,155 @@ func TestPolicyBuilder_GetStandardPolicies(t *testing.T) {
 	adminViewPolicy := policies[4]
 	assert.Equal(t, "arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminViewPolicy", adminViewPolicy.PolicyARN)
 	assert.Equal(t, "AmazonEKSAdminViewPolicy", adminViewPolicy.DisplayName)
+
+	// Standard policies of GovCloud clusters are in the aws-us-gov partition.
+	govPolicies := builder.getStandardPolicies(client.PartitionForRegion("us-gov-west-1"))
+	assert.Equal(t, "arn:aws-us-gov:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy", govPolicies[0].PolicyARN)
+}
+
+// recordingAccessPolicyClient records the principals access entries and policy associations are managed for.
+type recordingAccessPolicyClient struct {
+	mockAccessPolicyClient
+	createdEntries []string
+	associated     []string
+	disassociated  []string
+}
+
+func (m *recordingAccessPolicyClient) CreateAccessEntry(ctx context.Context, principalARN string) (*eksTypes.AccessEntry, error) {
+	m.createdEntries = append(m.createdEntries, principalARN)
+	return m.mockAccessPolicyClient.CreateAccessEntry(ctx, principalARN)
+}
+
+func (m *recordingAccessPolicyClient) AssociateAccessPolicy(ctx context.Context, principalARN string, policyARN string, accessScope *eksTypes.AccessScope) error {
+	m.associated = append(m.associated, principalARN)
+	return nil
+}
+
+func (m *recordingAccessPolicyClient) DisassociateAccessPolicy(ctx context.Context, principalARN string, policyARN string) error {
+	m.disassociated = append(m.disassociated, principalARN)
+	return nil
+}
+
+func TestPolicyBuilder_GrantPrincipals(t *testing.T) {
+	policy := &client.AccessPolicy{
+		PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy",
+		DisplayName: "AmazonEKSViewPolicy",
+	}
+
+	tests := []struct {
+		name      string
+		principal *v2.ResourceId
+		wantErr   bool
+	}{
+		{
+			name:      "iam user",
+			principal: &v2.ResourceId{ResourceType: ResourceTypeIAMUser.Id, Resource: "arn:aws:iam::123456789012:user/testuser"},
+		},
+		{
+			name:      "iam role",
+			principal: &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: "arn:aws:iam::123456789012:role/AWSReservedSSO_Admin_0123456789abcdef"},
+		},
+		{
+			name:      "unsupported principal",
+			principal: &v2.ResourceId{ResourceType: "service_account", Resource: "default/builder"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eksClient := &recordingAccessPolicyClient{}
+			builder := NewAccessPolicyBuilder(newTestClusterRegistry(false, withAccessPolicies(eksClient)), nil)
+
+			resource, err := builder.policyResource(policy)
+			require.NoError(t, err)
+			entitlements, _, _, err := builder.Entitlements(context.Background(), resource, nil)
+			require.NoError(t, err)
+
+			var clusterEnt *v2.Entitlement
+			for _, ent := range entitlements {
+				if ent.Slug == "assigned:cluster" {
+					clusterEnt = ent
+				}
+			}
+			require.NotNil(t, clusterEnt)
+
+			_, err = builder.Grant(context.Background(), &v2.Resource{Id: tt.principal}, clusterEnt)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Empty(t, eksClient.createdEntries)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.principal.Resource}, eksClient.createdEntries)
+			assert.Equal(t, []string{tt.principal.Resource}, eksClient.associated)
+
+			_, err = builder.Revoke(context.Background(), &v2.Grant{
+				Entitlement: clusterEnt,
+				Principal:   &v2.Resource{Id: tt.principal},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.principal.Resource}, eksClient.disassociated)
+		})
+	}
+}
+
+// snapshotAccessPolicyClient returns the given access entries as the snapshot of the cluster.
+type snapshotAccessPolicyClient struct {
+	mockAccessPolicyClient
+	entries []*client.AccessEntry
+}
+
+func (m *snapshotAccessPolicyClient) AccessEntries(ctx context.Context, refresh bool) (*client.AccessEntrySnapshot, error) {
+	return client.NewAccessEntrySnapshot(m.entries), nil
+}
+
+func TestPolicyBuilder_Grants(t *testing.T) {
+	const policyARN = "arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminPolicy"
+	eksClient := &snapshotAccessPolicyClient{
+		entries: []*client.AccessEntry{
+			{
+				PrincipalArn: "arn:aws:iam::123456789012:role/admin",
+				AssociatedPolicies: []eksTypes.AssociatedAccessPolicy{
+					{
+						PolicyArn:   aws.String(policyARN),
+						AccessScope: &eksTypes.AccessScope{Type: eksTypes.AccessScopeTypeNamespace, Namespaces: []string{"dev", "prod"}},
+					},
+				},
+			},
+			{
+				PrincipalArn: "arn:aws:iam::123456789012:user/bob",
+				AssociatedPolicies: []eksTypes.AssociatedAccessPolicy{
+					{
+						PolicyArn:   aws.String(policyARN),
+						AccessScope: &eksTypes.AccessScope{Type: eksTypes.AccessScopeTypeCluster},
+					},
+				},
+			},
+			{
+				PrincipalArn: "arn:aws:iam::123456789012:user/alice",
+				AssociatedPolicies: []eksTypes.AssociatedAccessPolicy{
+					{PolicyArn: aws.String("arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")},
+				},
+			},
+		},
+	}
+	builder := NewAccessPolicyBuilder(newTestClusterRegistry(false, withAccessPolicies(eksClient)), nil)
+
+	resource, err := builder.policyResource(&client.AccessPolicy{PolicyARN: policyARN, DisplayName: "AmazonEKSAdminPolicy"})
+	require.NoError(t, err)
+
+	grants, nextToken, _, err := builder.Grants(context.Background(), resource, &pagination.Token{})
+	require.NoError(t, err)
+	assert.Empty(t, nextToken)
+
+	var got []string
+	for _, g := range grants {
+		got = append(got, g.Entitlement.Id+" "+g.Principal.Id.ResourceType+" "+g.Principal.Id.Resource)
+	}
+	assert.Equal(t, []string{
+		"access_policy:" + policyARN + ":assigned:dev role arn:aws:iam::123456789012:role/admin",
+		"access_policy:" + policyARN + ":assigned:prod role arn:aws:iam::123456789012:role/admin",
+		"access_policy:" + policyARN + ":assigned:cluster iam_user arn:aws:iam::123456789012:user/bob",
+	}, got)
 }
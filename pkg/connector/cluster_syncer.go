@@ -0,0 +1,108 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+// clusterResourceSyncer runs a baton-kubernetes syncer against the cluster of the parent resource,
+// scoping the IDs of the resources it returns to their cluster.
+type clusterResourceSyncer struct {
+	resourceType *v2.ResourceType
+	clusters     *clusterRegistry
+	// childResourceTypes are added to the ChildResourceType annotations of the returned resources.
+	childResourceTypes []*v2.ResourceType
+}
+
+// ResourceType returns the resource type of the wrapped syncer.
+func (s *clusterResourceSyncer) ResourceType(ctx context.Context) *v2.ResourceType {
+	return s.resourceType
+}
+
+// syncerFor returns the baton-kubernetes syncer for the resource type in the given cluster.
+func (s *clusterResourceSyncer) syncerFor(ctx context.Context, c *eksCluster) (connectorbuilder.ResourceSyncer, error) {
+	for _, syncer := range c.k8s.ResourceSyncers(ctx) {
+		if syncer.ResourceType(ctx).Id == s.resourceType.Id {
+			return syncer, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s syncer for cluster %s", s.resourceType.Id, c.id)
+}
+
+// List fetches the resources of the cluster the parent resource belongs to.
+// Resources listed under an eks_cluster parent and without a parent of their own are parented to the cluster.
+func (s *clusterResourceSyncer) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	c, rawParentID, err := s.clusters.lookupParent(parentResourceID)
+	if err != nil || c == nil {
+		return nil, "", nil, err
+	}
+
+	// Cluster parents are an EKS concept, the wrapped syncer lists the whole cluster.
+	var parentID *v2.ResourceId
+	if parentResourceID.ResourceType != ResourceTypeEKSCluster.Id {
+		parentID = &v2.ResourceId{
+			ResourceType: parentResourceID.ResourceType,
+			Resource:     rawParentID,
+		}
+	}
+
+	syncer, err := s.syncerFor(ctx, c)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	rv, nextPageToken, annos, err := syncer.List(ctx, parentID, pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	for _, resource := range rv {
+		s.clusters.scopeResource(c, resource)
+		if resource.ParentResourceId == nil && parentID == nil {
+			resource.ParentResourceId = clusterResourceID(c)
+		}
+		resourceAnnos := annotations.Annotations(resource.Annotations)
+		for _, child := range s.childResourceTypes {
+			resourceAnnos.Append(&v2.ChildResourceType{ResourceTypeId: child.Id})
+		}
+		resource.Annotations = resourceAnnos
+	}
+	return rv, nextPageToken, annos, nil
+}
+
+// Entitlements returns the entitlements of the wrapped syncer for the resource.
+func (s *clusterResourceSyncer) Entitlements(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	c, _, err := s.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	syncer, err := s.syncerFor(ctx, c)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return syncer.Entitlements(ctx, resource, pToken)
+}
+
+// Grants returns the grants of the wrapped syncer for the resource.
+func (s *clusterResourceSyncer) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	c, _, err := s.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	syncer, err := s.syncerFor(ctx, c)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return syncer.Grants(ctx, resource, pToken)
+}
+
+func newClusterResourceSyncer(resourceType *v2.ResourceType, clusters *clusterRegistry, childResourceTypes ...*v2.ResourceType) *clusterResourceSyncer {
+	return &clusterResourceSyncer{
+		resourceType:       resourceType,
+		clusters:           clusters,
+		childResourceTypes: childResourceTypes,
+	}
+}
@@ -7,13 +7,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/conductorone/baton-eks/pkg/client"
 	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"k8s.io/client-go/kubernetes"
-
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
@@ -29,13 +26,12 @@ const clusterScopedMember = "all:member"
 
 // clusterRoleBuilder syncs Kubernetes ClusterRoles as Baton resources.
 type clusterRoleBuilder struct {
-	client          kubernetes.Interface
-	bindingProvider k8s.ClusterRoleBindingProvider
-	// Cached namespaces
-	cachedNamespaces []string
+	clusters   *clusterRegistry
+	identities *identityMapper
+	// Cached namespaces, by cluster ID
+	cachedNamespaces map[string][]string
 	nsMutex          sync.Mutex
-	nsCacheExpiry    time.Time
-	eksService       *client.EKSClient
+	nsCacheExpiry    map[string]time.Time
 }
 
 // ResourceType returns the resource type for ClusterRole.
@@ -43,17 +39,30 @@ func (c *clusterRoleBuilder) ResourceType(ctx context.Context) *v2.ResourceType
 	return k8s.ResourceTypeClusterRole
 }
 
-// List fetches all ClusterRoles from the Kubernetes API.
+// List fetches the ClusterRoles of the parent cluster from the Kubernetes API.
 func (c *clusterRoleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	cluster, _, err := c.clusters.lookupParent(parentResourceID)
+	if err != nil || cluster == nil {
+		return nil, "", nil, err
+	}
+	rv, nextPageToken, err := c.listClusterRoles(ctx, cluster, pToken.Token)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return rv, nextPageToken, nil, nil
+}
+
+// listClusterRoles fetches a page of ClusterRoles from the Kubernetes API of a cluster.
+func (c *clusterRoleBuilder) listClusterRoles(ctx context.Context, cluster *eksCluster, pageToken string) ([]*v2.Resource, string, error) {
 	l := ctxzap.Extract(ctx)
 
 	// Initialize empty resource slice.
 	var rv []*v2.Resource
 
 	// Parse pagination token.
-	bag, err := k8s.ParsePageToken(pToken.Token)
+	bag, err := k8s.ParsePageToken(pageToken)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to parse page token: %w", err)
+		return nil, "", fmt.Errorf("failed to parse page token: %w", err)
 	}
 
 	// Set up list options with pagination.
@@ -63,10 +72,10 @@ func (c *clusterRoleBuilder) List(ctx context.Context, parentResourceID *v2.Reso
 	}
 
 	// Fetch cluster roles from the Kubernetes API.
-	l.Debug("fetching cluster roles", zap.String("continue_token", opts.Continue))
-	resp, err := c.client.RbacV1().ClusterRoles().List(ctx, opts)
+	l.Debug("fetching cluster roles", zap.String("cluster", cluster.id), zap.String("continue_token", opts.Continue))
+	resp, err := cluster.kube.RbacV1().ClusterRoles().List(ctx, opts)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to list cluster roles: %w", err)
+		return nil, "", fmt.Errorf("failed to list cluster roles: %w", err)
 	}
 
 	// Process each cluster role into a Baton resource.
@@ -78,16 +87,18 @@ func (c *clusterRoleBuilder) List(ctx context.Context, parentResourceID *v2.Reso
 				zap.Error(err))
 			continue
 		}
+		resource = c.clusters.scopeResource(cluster, resource)
+		resource.ParentResourceId = clusterResourceID(cluster)
 		rv = append(rv, resource)
 	}
 
 	// Calculate next page token.
 	nextPageToken, err := k8s.HandleKubePagination(&resp.ListMeta, bag)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to handle pagination: %w", err)
+		return nil, "", fmt.Errorf("failed to handle pagination: %w", err)
 	}
 
-	return rv, nextPageToken, nil, nil
+	return rv, nextPageToken, nil
 }
 
 // clusterRoleResource creates a Baton resource from a Kubernetes ClusterRole.
@@ -145,12 +156,16 @@ func (c *clusterRoleBuilder) Entitlements(ctx context.Context, resource *v2.Reso
 
 	// Each ClusterRole can be granted in a RoleBinding, thus binding it to a namespace.
 	// Create entitlements for each namespace.
-	err := c.cacheNamespaces(ctx)
+	cluster, _, err := c.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	namespaces, err := c.getNamespaces(ctx, cluster)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to cache namespaces: %w", err)
 	}
 
-	for _, ns := range c.cachedNamespaces {
+	for _, ns := range namespaces {
 		entitlementName := fmt.Sprintf("%s:%s", ns, "member")
 		nsEnt := entitlement.NewAssignmentEntitlement(
 			resource,
@@ -178,10 +193,13 @@ func (c *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource,
 	if resource.Id == nil || resource.Id.Resource == "" {
 		return nil, "", nil, fmt.Errorf("invalid resource ID")
 	}
-	name := resource.Id.Resource
+	cluster, name, err := c.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
 
 	// Get matching role bindings and cluster role bindings from the binding provider.
-	matchingRoleBindings, matchingClusterBindings, err := c.bindingProvider.GetMatchingBindingsForClusterRole(ctx, name)
+	matchingRoleBindings, matchingClusterBindings, err := cluster.bindings.GetMatchingBindingsForClusterRole(ctx, name)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to get matching bindings: %w", err)
 	}
@@ -192,6 +210,8 @@ func (c *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource,
 		return nil, "", nil, nil
 	}
 
+	users := newUsernameGrants(resource)
+
 	// Process each matching cluster binding.
 	for _, binding := range matchingClusterBindings {
 		// Process each subject in the binding.
@@ -199,7 +219,7 @@ func (c *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource,
 			if subject.Kind == k8s.SubjectKindServiceAccount {
 				// These are Cluster's local service accounts, not AWS.
 				saName := fmt.Sprintf("%s/%s", subject.Namespace, subject.Name) // SA are always namespaced, even if they can have cluster roles bind to cluster level.
-				saResource := k8s.GenerateResourceForGrant(saName, k8s.ResourceTypeServiceAccount.Id)
+				saResource := k8s.GenerateResourceForGrant(c.clusters.scopeID(cluster, saName), k8s.ResourceTypeServiceAccount.Id)
 				g := grant.NewGrant(
 					resource,
 					clusterScopedMember,
@@ -208,20 +228,20 @@ func (c *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource,
 				rv = append(rv, g)
 			} else if (subject.APIGroup == k8s.RBACAPIGroup || subject.APIGroup == k8s.RBACAPIGroupV1) &&
 				!strings.Contains(subject.Name, "system:") {
-				var matchingARNs []string
 				switch subject.Kind {
 				case k8s.SubjectKindGroup:
-					matchingARNs, err = c.eksService.LookupArnsByGroup(ctx, subject.Name)
+					matchingARNs, err := c.identities.groupMembers(ctx, cluster, subject.Name)
 					if err != nil {
 						return nil, "", nil, fmt.Errorf("failed to lookup ARNs for group %s: %w", subject.Name, err)
 					}
+					rv = append(rv, processGrants(matchingARNs, resource, clusterScopedMember)...)
 				case k8s.SubjectKindUser:
-					matchingARNs, err = c.eksService.LookupArnsByUsername(ctx, subject.Name)
+					matches, err := c.identities.usernamePrincipals(ctx, cluster, subject.Name)
 					if err != nil {
 						return nil, "", nil, fmt.Errorf("failed to lookup ARNs for user %s: %w", subject.Name, err)
 					}
+					users.add(clusterScopedMember, subject.Name, matches)
 				}
-				rv = append(rv, processGrants(matchingARNs, resource, clusterScopedMember)...)
 			}
 		}
 	}
@@ -235,7 +255,7 @@ func (c *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource,
 			if subject.Kind == k8s.SubjectKindServiceAccount {
 				// These are Cluster's local service accounts, not AWS.
 				saName := fmt.Sprintf("%s/%s", subject.Namespace, subject.Name)
-				saResource := k8s.GenerateResourceForGrant(saName, k8s.ResourceTypeServiceAccount.Id)
+				saResource := k8s.GenerateResourceForGrant(c.clusters.scopeID(cluster, saName), k8s.ResourceTypeServiceAccount.Id)
 				g := grant.NewGrant(
 					resource,
 					clusterScopedMember,
@@ -245,36 +265,38 @@ func (c *clusterRoleBuilder) Grants(ctx context.Context, resource *v2.Resource,
 			}
 			if (subject.APIGroup == k8s.RBACAPIGroup || subject.APIGroup == k8s.RBACAPIGroupV1) &&
 				!strings.Contains(subject.Name, "system:") {
-				var matchingARNs []string
 				switch subject.Kind {
 				case k8s.SubjectKindGroup:
-					matchingARNs, err = c.eksService.LookupArnsByGroup(ctx, subject.Name)
+					matchingARNs, err := c.identities.groupMembers(ctx, cluster, subject.Name)
 					if err != nil {
 						return nil, "", nil, fmt.Errorf("failed to lookup ARNs for group %s: %w", subject.Name, err)
 					}
+					rv = append(rv, processGrants(matchingARNs, resource, entName)...)
 				case k8s.SubjectKindUser:
-					matchingARNs, err = c.eksService.LookupArnsByUsername(ctx, subject.Name)
+					matches, err := c.identities.usernamePrincipals(ctx, cluster, subject.Name)
 					if err != nil {
 						return nil, "", nil, fmt.Errorf("failed to lookup ARNs for user %s: %w", subject.Name, err)
 					}
+					users.add(entName, subject.Name, matches)
 				}
-				rv = append(rv, processGrants(matchingARNs, resource, entName)...)
 			}
 		}
 	}
 
+	rv = append(rv, users.grants()...)
+
 	return rv, "", nil, nil
 }
 
-// getNamespaces returns cached namespaces or fetches them if cache is expired or empty.
-func (c *clusterRoleBuilder) cacheNamespaces(ctx context.Context) error {
+// getNamespaces returns cached namespaces of a cluster or fetches them if cache is expired or empty.
+func (c *clusterRoleBuilder) getNamespaces(ctx context.Context, cluster *eksCluster) ([]string, error) {
 	c.nsMutex.Lock()
 	defer c.nsMutex.Unlock()
 
 	now := time.Now()
-	if c.cachedNamespaces != nil && now.Before(c.nsCacheExpiry) {
+	if cached, ok := c.cachedNamespaces[cluster.id]; ok && now.Before(c.nsCacheExpiry[cluster.id]) {
 		// Cache is valid.
-		return nil
+		return cached, nil
 	}
 	var (
 		names      []string
@@ -284,9 +306,9 @@ func (c *clusterRoleBuilder) cacheNamespaces(ctx context.Context) error {
 		opts := metav1.ListOptions{
 			Continue: continueAt,
 		}
-		nsList, err := c.client.CoreV1().Namespaces().List(ctx, opts)
+		nsList, err := cluster.kube.CoreV1().Namespaces().List(ctx, opts)
 		if err != nil {
-			return fmt.Errorf("failed to cache namespaces list: %w", err)
+			return nil, fmt.Errorf("failed to cache namespaces list: %w", err)
 		}
 		for _, ns := range nsList.Items {
 			names = append(names, ns.Name)
@@ -297,16 +319,17 @@ func (c *clusterRoleBuilder) cacheNamespaces(ctx context.Context) error {
 		continueAt = nsList.Continue
 	}
 
-	c.cachedNamespaces = names
-	c.nsCacheExpiry = now.Add(cacheTTL)
-	return nil
+	c.cachedNamespaces[cluster.id] = names
+	c.nsCacheExpiry[cluster.id] = now.Add(cacheTTL)
+	return names, nil
 }
 
 // newClusterRoleBuilder creates a new cluster role builder.
-func NewClusterRoleBuilder(client kubernetes.Interface, bindingProvider k8s.ClusterRoleBindingProvider, provider *client.EKSClient) *clusterRoleBuilder {
+func NewClusterRoleBuilder(clusters *clusterRegistry, identities *identityMapper) *clusterRoleBuilder {
 	return &clusterRoleBuilder{
-		client:          client,
-		bindingProvider: bindingProvider,
-		eksService:      provider,
+		clusters:         clusters,
+		identities:       identities,
+		cachedNamespaces: make(map[string][]string),
+		nsCacheExpiry:    make(map[string]time.Time),
 	}
 }
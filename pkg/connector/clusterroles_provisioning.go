@@ -19,8 +19,9 @@ func (c *clusterRoleBuilder) Grant(ctx context.Context, principal *v2.Resource,
 		username    string
 	)
 
-	if principal.Id.ResourceType != ResourceTypeIAMUser.Id {
-		return nil, fmt.Errorf("principal type is not supported")
+	principalARN, err := iamPrincipalARN(principal.Id)
+	if err != nil {
+		return nil, err
 	}
 
 	// Extract entitlement ID to determine scope
@@ -29,7 +30,12 @@ func (c *clusterRoleBuilder) Grant(ctx context.Context, principal *v2.Resource,
 		return nil, fmt.Errorf("invalid entitlement ID")
 	}
 
-	username, err = getOrCreateUsername(ctx, c.eksService, principal)
+	cluster, clusterRoleName, err := c.clusters.lookup(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	username, err = c.identities.getOrCreateUsername(ctx, cluster, principalARN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get or create username: %w", err)
 	}
@@ -43,18 +49,17 @@ func (c *clusterRoleBuilder) Grant(ctx context.Context, principal *v2.Resource,
 	// Create the appropriate binding based on scope
 	if namespace == "" {
 		// Cluster-scoped binding
-		annotations, err = c.handleClusterRoleBinding(ctx, entitlement, username)
+		annotations, err = c.handleClusterRoleBinding(ctx, cluster, clusterRoleName, username)
 		if err != nil {
 			return nil, fmt.Errorf("failed to handle cluster role binding: %w", err)
 		}
 	} else {
-		clusterRoleName := entitlement.Resource.Id.Resource
-		matchingRoleBindings, _, err := c.bindingProvider.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
+		matchingRoleBindings, _, err := cluster.bindings.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get matching bindings: %w", err)
 		}
 		// Namespace-scoped binding
-		annotations, err = handleRoleBinding(ctx, c.eksService, namespace, username, "ClusterRole", matchingRoleBindings, clusterRoleName)
+		annotations, err = handleRoleBinding(ctx, cluster.eksClient, namespace, username, "ClusterRole", matchingRoleBindings, clusterRoleName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to handle role binding: %w", err)
 		}
@@ -63,10 +68,9 @@ func (c *clusterRoleBuilder) Grant(ctx context.Context, principal *v2.Resource,
 	return annotations, nil
 }
 
-func (c *clusterRoleBuilder) handleClusterRoleBinding(ctx context.Context, entitlement *v2.Entitlement, username string) (annotations.Annotations, error) {
-	clusterRoleName := entitlement.Resource.Id.Resource
+func (c *clusterRoleBuilder) handleClusterRoleBinding(ctx context.Context, cluster *eksCluster, clusterRoleName string, username string) (annotations.Annotations, error) {
 	bindingName := fmt.Sprintf("baton-%s-binding", clusterRoleName)
-	_, matchingClusterBindings, err := c.bindingProvider.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
+	_, matchingClusterBindings, err := cluster.bindings.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matching bindings: %w", err)
 	}
@@ -87,7 +91,7 @@ func (c *clusterRoleBuilder) handleClusterRoleBinding(ctx context.Context, entit
 				Name:     username,
 				APIGroup: rbacv1.GroupName,
 			})
-			err = c.eksService.UpdateClusterRoleBinding(ctx, bindingToUpdate)
+			err = cluster.eksClient.UpdateClusterRoleBinding(ctx, bindingToUpdate)
 			if err != nil {
 				return nil, fmt.Errorf("failed to update cluster role binding: %w", err)
 			}
@@ -95,7 +99,7 @@ func (c *clusterRoleBuilder) handleClusterRoleBinding(ctx context.Context, entit
 		}
 	}
 	// Binding doesn't exist, create a new binding.
-	err = c.createClusterRoleBinding(ctx, clusterRoleName, username)
+	err = c.createClusterRoleBinding(ctx, cluster, clusterRoleName, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cluster role binding: %w", err)
 	}
@@ -103,7 +107,7 @@ func (c *clusterRoleBuilder) handleClusterRoleBinding(ctx context.Context, entit
 	return nil, nil
 }
 
-func (c *clusterRoleBuilder) createClusterRoleBinding(ctx context.Context, clusterRoleName string, subjectName string) error {
+func (c *clusterRoleBuilder) createClusterRoleBinding(ctx context.Context, cluster *eksCluster, clusterRoleName string, subjectName string) error {
 	bindingName := fmt.Sprintf("baton-%s-binding", clusterRoleName)
 	subjects := []rbacv1.Subject{
 		{
@@ -112,7 +116,7 @@ func (c *clusterRoleBuilder) createClusterRoleBinding(ctx context.Context, clust
 			APIGroup: rbacv1.GroupName,
 		},
 	}
-	err := c.eksService.CreateClusterRoleBinding(ctx, bindingName, clusterRoleName, subjects)
+	err := cluster.eksClient.CreateClusterRoleBinding(ctx, bindingName, clusterRoleName, subjects)
 	if err != nil {
 		return fmt.Errorf("failed to create cluster role binding: %w", err)
 	}
@@ -128,7 +132,10 @@ func (c *clusterRoleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annot
 	if grant.Entitlement == nil || grant.Entitlement.Resource == nil || grant.Entitlement.Resource.Id == nil {
 		return nil, fmt.Errorf("invalid grant: missing entitlement resource")
 	}
-	clusterRoleName := grant.Entitlement.Resource.Id.Resource
+	cluster, clusterRoleName, err := c.clusters.lookup(grant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
 
 	// Extract entitlement ID to determine scope
 	entitlementID := grant.Entitlement.Id
@@ -148,13 +155,13 @@ func (c *clusterRoleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annot
 		return nil, fmt.Errorf("invalid principal")
 	}
 
-	iamUserMap, err := c.eksService.GetMapUserFromAWSAuthConfigMap(ctx, principal.Id.Resource)
+	username, found, err := c.identities.lookupUsername(ctx, cluster, principal.Id.Resource)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get map user from aws-auth ConfigMap: %w", err)
+		return nil, fmt.Errorf("failed to look up kubernetes username: %w", err)
 	}
 
-	if iamUserMap == nil {
-		l.Debug("no mapping found in aws-auth ConfigMap for the principal, returning GrantAlreadyRevoked",
+	if !found {
+		l.Debug("no kubernetes username mapped for the principal, returning GrantAlreadyRevoked",
 			zap.String("principal", principal.Id.Resource),
 		)
 		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
@@ -163,19 +170,21 @@ func (c *clusterRoleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annot
 	// Revoke the appropriate binding based on scope
 	if namespace == "" {
 		// Cluster-scoped binding
-		annos, err = c.revokeClusterRoleBinding(ctx, clusterRoleName, iamUserMap.Username)
+		annos, err = c.revokeClusterRoleBinding(ctx, cluster, clusterRoleName, username)
 		if err != nil {
 			return nil, fmt.Errorf("failed to revoke cluster role binding: %w", err)
 		}
 	} else {
 		// Namespace-scoped binding
-		annos, err = c.revokeRoleBinding(ctx, clusterRoleName, namespace, iamUserMap.Username)
+		annos, err = c.revokeRoleBinding(ctx, cluster, clusterRoleName, namespace, username)
 		if err != nil {
 			return nil, fmt.Errorf("failed to revoke role binding: %w", err)
 		}
 	}
+	c.identities.releaseUsername(ctx, cluster, principal.Id.Resource, username)
 
 	l.Info("successfully revoked cluster role access",
+		zap.String("cluster", cluster.id),
 		zap.String("cluster_role", clusterRoleName),
 		zap.String("principal", principal.Id.Resource),
 		zap.String("namespace", namespace),
@@ -185,9 +194,9 @@ func (c *clusterRoleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annot
 }
 
 // revokeClusterRoleBinding removes a subject from a ClusterRoleBinding.
-func (c *clusterRoleBuilder) revokeClusterRoleBinding(ctx context.Context, clusterRoleName string, username string) (annotations.Annotations, error) {
+func (c *clusterRoleBuilder) revokeClusterRoleBinding(ctx context.Context, cluster *eksCluster, clusterRoleName string, username string) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
-	_, matchingClusterBindings, err := c.bindingProvider.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
+	_, matchingClusterBindings, err := cluster.bindings.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matching bindings: %w", err)
 	}
@@ -200,7 +209,7 @@ func (c *clusterRoleBuilder) revokeClusterRoleBinding(ctx context.Context, clust
 		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
 	}
 	newSubjects := filterSubjects(bindingToUpdate.Subjects, username)
-	err = handleBindingUpdateOrDelete(ctx, c.eksService, bindingToUpdate, newSubjects, "")
+	err = handleBindingUpdateOrDelete(ctx, cluster.eksClient, bindingToUpdate, newSubjects, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to handle cluster role binding update/delete: %w", err)
 	}
@@ -209,10 +218,10 @@ func (c *clusterRoleBuilder) revokeClusterRoleBinding(ctx context.Context, clust
 }
 
 // revokeRoleBinding removes a subject from a RoleBinding.
-func (c *clusterRoleBuilder) revokeRoleBinding(ctx context.Context, clusterRoleName string, namespace string, username string) (annotations.Annotations, error) {
-	matchingRoleBindings, _, err := c.bindingProvider.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
+func (c *clusterRoleBuilder) revokeRoleBinding(ctx context.Context, cluster *eksCluster, clusterRoleName string, namespace string, username string) (annotations.Annotations, error) {
+	matchingRoleBindings, _, err := cluster.bindings.GetMatchingBindingsForClusterRole(ctx, clusterRoleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matching bindings: %w", err)
 	}
-	return handleRevokeRoleBinding(ctx, c.eksService, namespace, username, matchingRoleBindings, clusterRoleName)
+	return handleRevokeRoleBinding(ctx, cluster.eksClient, namespace, username, matchingRoleBindings, clusterRoleName)
 }
@@ -0,0 +1,311 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/conductorone/baton-eks/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	awsAuthPath             = "/api/v1/namespaces/kube-system/configmaps/aws-auth"
+	clusterRoleBindingsPath = "/apis/rbac.authorization.k8s.io/v1/clusterrolebindings"
+	accessEntriesPath       = "/clusters/test-cluster/access-entries"
+)
+
+// fakeKubeAPI serves the aws-auth ConfigMap, ClusterRoleBindings and access entries of a cluster,
+// and provides the cluster's bindings from what was written to it.
+type fakeKubeAPI struct {
+	t                   *testing.T
+	mtx                 sync.Mutex
+	version             int
+	awsAuth             *corev1.ConfigMap
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+	accessEntryARNs     []string
+	accessEntries       map[string]map[string]interface{}
+	associatedPolicies  map[string][]map[string]interface{}
+}
+
+func (f *fakeKubeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, accessEntriesPath):
+		f.serveAccessEntries(w, r)
+	case r.URL.Path == awsAuthPath && r.Method == http.MethodGet:
+		if f.awsAuth == nil {
+			f.notFound(w, "configmaps", "aws-auth")
+			return
+		}
+		f.write(w, f.awsAuth)
+	case (r.URL.Path == awsAuthPath && r.Method == http.MethodPut) ||
+		(r.URL.Path == "/api/v1/namespaces/kube-system/configmaps" && r.Method == http.MethodPost):
+		configMap := &corev1.ConfigMap{}
+		f.read(r, configMap)
+		f.version++
+		configMap.ResourceVersion = strconv.Itoa(f.version)
+		f.awsAuth = configMap
+		f.write(w, configMap)
+	case r.URL.Path == clusterRoleBindingsPath && r.Method == http.MethodPost:
+		binding := rbacv1.ClusterRoleBinding{}
+		f.read(r, &binding)
+		f.clusterRoleBindings = append(f.clusterRoleBindings, binding)
+		f.write(w, &binding)
+	case strings.HasPrefix(r.URL.Path, clusterRoleBindingsPath+"/") && r.Method == http.MethodPut:
+		binding := rbacv1.ClusterRoleBinding{}
+		f.read(r, &binding)
+		for i := range f.clusterRoleBindings {
+			if f.clusterRoleBindings[i].Name == binding.Name {
+				f.clusterRoleBindings[i] = binding
+			}
+		}
+		f.write(w, &binding)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// serveAccessEntries serves the EKS access entry API of the cluster, keeping the entries and policies it's given.
+func (f *fakeKubeAPI) serveAccessEntries(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), accessEntriesPath)
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			f.write(w, map[string]interface{}{"accessEntries": append([]string{}, f.accessEntryARNs...)})
+		case http.MethodPost:
+			input := map[string]interface{}{}
+			f.read(r, &input)
+			principalARN, _ := input["principalArn"].(string)
+			if _, ok := f.accessEntries[principalARN]; ok {
+				f.eksError(w, http.StatusConflict, "ResourceInUseException")
+				return
+			}
+			// Like EKS, the default username of roles is templated by session.
+			username := input["username"]
+			if username == nil && client.IsIAMRoleARN(principalARN) {
+				account := strings.Split(principalARN, ":")[4]
+				roleName := principalARN[strings.LastIndex(principalARN, "/")+1:]
+				username = "arn:aws:sts::" + account + ":assumed-role/" + roleName + "/{{SessionName}}"
+			}
+			entry := map[string]interface{}{
+				"clusterName":      "test-cluster",
+				"principalArn":     principalARN,
+				"username":         username,
+				"kubernetesGroups": input["kubernetesGroups"],
+				"type":             "STANDARD",
+			}
+			if f.accessEntries == nil {
+				f.accessEntries = map[string]map[string]interface{}{}
+			}
+			f.accessEntries[principalARN] = entry
+			f.accessEntryARNs = append(f.accessEntryARNs, principalARN)
+			f.write(w, map[string]interface{}{"accessEntry": entry})
+		default:
+			f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+		return
+	}
+
+	escapedARN, subresource, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	principalARN, err := url.PathUnescape(escapedARN)
+	assert.NoError(f.t, err)
+	entry, ok := f.accessEntries[principalARN]
+	if !ok {
+		f.eksError(w, http.StatusNotFound, "ResourceNotFoundException")
+		return
+	}
+	switch {
+	case subresource == "" && r.Method == http.MethodGet:
+		f.write(w, map[string]interface{}{"accessEntry": entry})
+	case subresource == "access-policies" && r.Method == http.MethodGet:
+		f.write(w, map[string]interface{}{
+			"clusterName":              "test-cluster",
+			"principalArn":             principalARN,
+			"associatedAccessPolicies": append([]map[string]interface{}{}, f.associatedPolicies[principalARN]...),
+		})
+	case subresource == "access-policies" && r.Method == http.MethodPost:
+		input := map[string]interface{}{}
+		f.read(r, &input)
+		policy := map[string]interface{}{"policyArn": input["policyArn"], "accessScope": input["accessScope"]}
+		if f.associatedPolicies == nil {
+			f.associatedPolicies = map[string][]map[string]interface{}{}
+		}
+		f.associatedPolicies[principalARN] = append(f.associatedPolicies[principalARN], policy)
+		f.write(w, map[string]interface{}{
+			"clusterName":            "test-cluster",
+			"principalArn":           principalARN,
+			"associatedAccessPolicy": policy,
+		})
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeKubeAPI) eksError(w http.ResponseWriter, status int, errorType string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Amzn-Errortype", errorType)
+	w.WriteHeader(status)
+	assert.NoError(f.t, json.NewEncoder(w).Encode(map[string]string{"message": errorType}))
+}
+
+func (f *fakeKubeAPI) read(r *http.Request, v interface{}) {
+	assert.NoError(f.t, json.NewDecoder(r.Body).Decode(v))
+}
+
+func (f *fakeKubeAPI) write(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	assert.NoError(f.t, json.NewEncoder(w).Encode(v))
+}
+
+func (f *fakeKubeAPI) notFound(w http.ResponseWriter, resource string, name string) {
+	status := apierrors.NewNotFound(schema.GroupResource{Resource: resource}, name).ErrStatus
+	status.Kind = "Status"
+	status.APIVersion = "v1"
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	assert.NoError(f.t, json.NewEncoder(w).Encode(status))
+}
+
+func (f *fakeKubeAPI) GetMatchingBindingsForClusterRole(ctx context.Context, clusterRoleName string) ([]rbacv1.RoleBinding, []rbacv1.ClusterRoleBinding, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	var bindings []rbacv1.ClusterRoleBinding
+	for _, binding := range f.clusterRoleBindings {
+		if binding.RoleRef.Name == clusterRoleName {
+			bindings = append(bindings, binding)
+		}
+	}
+	return nil, bindings, nil
+}
+
+func (f *fakeKubeAPI) GetMatchingRoleBindings(ctx context.Context, namespace, roleName string) ([]rbacv1.RoleBinding, error) {
+	return nil, nil
+}
+
+// newFakeKubeCluster returns a CONFIG_MAP mode cluster whose Kubernetes and EKS APIs are served by a fakeKubeAPI.
+func newFakeKubeCluster(t *testing.T) (*eksCluster, *fakeKubeAPI) {
+	api := &fakeKubeAPI{t: t}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	eksSDKClient := eks.New(eks.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   server.Client(),
+	})
+	eksClient, err := client.NewEKSClient(&rest.Config{
+		Host:          server.URL,
+		ContentConfig: rest.ContentConfig{ContentType: "application/json"},
+		QPS:           -1,
+	}, nil, eksSDKClient, "test-cluster", 0)
+	require.NoError(t, err)
+
+	return newTestCluster(
+		withBindings(api),
+		withEKSClient(eksClient),
+		withInfo(&mockClusterClient{authenticationMode: eksTypes.AuthenticationModeConfigMap}),
+	), api
+}
+
+// mockRoleARNResolver resolves the role ARNs of aws-auth from a fixed set of roles.
+type mockRoleARNResolver map[string]string
+
+func (m mockRoleARNResolver) ResolveRoleARN(ctx context.Context, roleARN string) (string, error) {
+	if resolved, ok := m[roleARN]; ok {
+		return resolved, nil
+	}
+	return roleARN, nil
+}
+
+func TestClusterRoleBuilder_GrantAndSyncRoleWithPath(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/team/deployer"
+	cluster, api := newFakeKubeCluster(t)
+	identities := newIdentityMapper(false, mockRoleARNResolver{
+		"arn:aws:iam::123456789012:role/deployer": roleARN,
+	})
+	builder := NewClusterRoleBuilder(newClusterRegistry(false, cluster), identities)
+	ctx := context.Background()
+
+	resource, err := clusterRoleResource(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}})
+	require.NoError(t, err)
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: roleARN}}
+	ent := &v2.Entitlement{Id: entitlement.NewEntitlementID(resource, clusterScopedMember), Resource: resource}
+
+	_, err = builder.Grant(ctx, principal, ent)
+	require.NoError(t, err)
+
+	// aws-auth maps the role without its path, which is also its username.
+	require.NotNil(t, api.awsAuth)
+	assert.Contains(t, api.awsAuth.Data["mapRoles"], "rolearn: arn:aws:iam::123456789012:role/deployer")
+	require.Len(t, api.clusterRoleBindings, 1)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/deployer", api.clusterRoleBindings[0].Subjects[0].Name)
+
+	grants, _, _, err := builder.Grants(ctx, resource, &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, ResourceTypeIAMRole.Id, grants[0].Principal.Id.ResourceType)
+	assert.Equal(t, roleARN, grants[0].Principal.Id.Resource)
+	assert.Equal(t, ent.Id, grants[0].Entitlement.Id)
+}
+
+func TestClusterRoleBuilder_GrantAfterAccessPolicyGrant(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/deployer"
+	cluster, api := newFakeKubeCluster(t)
+	cluster.info = &mockClusterClient{authenticationMode: eksTypes.AuthenticationModeApi}
+	registry := newClusterRegistry(false, cluster)
+	ctx := context.Background()
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: roleARN}}
+
+	// Granting an access policy creates the role's access entry.
+	policies := NewAccessPolicyBuilder(registry, nil)
+	policyResource, err := policies.policyResource(&client.AccessPolicy{
+		PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy",
+		DisplayName: "AmazonEKSViewPolicy",
+	})
+	require.NoError(t, err)
+	_, err = policies.Grant(ctx, principal, &v2.Entitlement{
+		Id:       entitlement.NewEntitlementID(policyResource, "assigned:cluster"),
+		Resource: policyResource,
+	})
+	require.NoError(t, err)
+	require.Contains(t, api.accessEntries, roleARN)
+	assert.Equal(t, roleARN, api.accessEntries[roleARN]["username"])
+
+	// The role can then be bound to a cluster role through the username of that access entry.
+	builder := NewClusterRoleBuilder(registry, newIdentityMapper(true, mockRoleARNResolver{}))
+	resource, err := clusterRoleResource(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}})
+	require.NoError(t, err)
+	_, err = builder.Grant(ctx, principal, &v2.Entitlement{Id: entitlement.NewEntitlementID(resource, clusterScopedMember), Resource: resource})
+	require.NoError(t, err)
+
+	assert.Nil(t, api.awsAuth)
+	require.Len(t, api.clusterRoleBindings, 1)
+	assert.Equal(t, roleARN, api.clusterRoleBindings[0].Subjects[0].Name)
+}
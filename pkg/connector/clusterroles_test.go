@@ -6,15 +6,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/conductorone/baton-eks/pkg/client"
 	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-// MockClusterRoleBindingProvider implements k8s.ClusterRoleBindingProvider for testing.
+// MockClusterRoleBindingProvider implements k8s.ClusterRoleBindingProvider and k8s.RoleBindingProvider for testing.
 type MockClusterRoleBindingProvider struct {
 	roleBindings    []rbacv1.RoleBinding
 	clusterBindings []rbacv1.ClusterRoleBinding
@@ -28,10 +32,19 @@ func (m *MockClusterRoleBindingProvider) GetMatchingBindingsForClusterRole(ctx c
 	return m.roleBindings, m.clusterBindings, nil
 }
 
+func (m *MockClusterRoleBindingProvider) GetMatchingRoleBindings(ctx context.Context, namespace, roleName string) ([]rbacv1.RoleBinding, error) {
+	if m.shouldReturnErr {
+		return nil, assert.AnError
+	}
+	return m.roleBindings, nil
+}
+
 // MockEKSClient implements client.EKSClient for testing.
 type MockEKSClient struct {
 	usersByGroup    map[string][]string
 	usersByUsername map[string][]string
+	usernameMatches map[string][]client.UsernameMatch
+	mappedAccounts  []string
 	shouldReturnErr bool
 }
 
@@ -45,14 +58,46 @@ func (m *MockEKSClient) LookupArnsByGroup(ctx context.Context, groupName string)
 	return []string{}, nil
 }
 
-func (m *MockEKSClient) LookupArnsByUsername(ctx context.Context, username string) ([]string, error) {
+func (m *MockEKSClient) ListKubernetesGroups(ctx context.Context) ([]string, error) {
 	if m.shouldReturnErr {
 		return nil, assert.AnError
 	}
-	if users, exists := m.usersByUsername[username]; exists {
-		return users, nil
+	var groups []string
+	for group := range m.usersByGroup {
+		groups = append(groups, group)
 	}
-	return []string{}, nil
+	return groups, nil
+}
+
+func (m *MockEKSClient) ListMappedPrincipals(ctx context.Context) ([]string, error) {
+	if m.shouldReturnErr {
+		return nil, assert.AnError
+	}
+	var principals []string
+	for _, mappings := range []map[string][]string{m.usersByGroup, m.usersByUsername} {
+		for _, arns := range mappings {
+			principals = append(principals, arns...)
+		}
+	}
+	return principals, nil
+}
+
+func (m *MockEKSClient) ListMappedAccounts(ctx context.Context) ([]string, error) {
+	if m.shouldReturnErr {
+		return nil, assert.AnError
+	}
+	return m.mappedAccounts, nil
+}
+
+func (m *MockEKSClient) LookupArnsByUsername(ctx context.Context, username string) ([]client.UsernameMatch, error) {
+	if m.shouldReturnErr {
+		return nil, assert.AnError
+	}
+	var matches []client.UsernameMatch
+	for _, arn := range m.usersByUsername[username] {
+		matches = append(matches, client.UsernameMatch{ARN: arn})
+	}
+	return append(matches, m.usernameMatches[username]...), nil
 }
 
 func TestClusterRoleResource(t *testing.T) {
@@ -114,7 +159,7 @@ func TestClusterRoleResource(t *testing.T) {
 func TestClusterRoleBuilder_ResourceType(t *testing.T) {
 	mockBindingProvider := &MockClusterRoleBindingProvider{}
 
-	builder := NewClusterRoleBuilder(nil, mockBindingProvider, nil)
+	builder := NewClusterRoleBuilder(newTestClusterRegistry(false, withBindings(mockBindingProvider)), newIdentityMapper(false, nil))
 
 	ctx := t.Context()
 	resourceType := builder.ResourceType(ctx)
@@ -175,3 +220,63 @@ func TestProcessGrants(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessGrants_Partitions(t *testing.T) {
+	resource := &v2.Resource{
+		Id: &v2.ResourceId{
+			ResourceType: k8s.ResourceTypeClusterRole.Id,
+			Resource:     "test-role",
+		},
+	}
+
+	grants := processGrants([]string{
+		"arn:aws-us-gov:iam::123456789012:role/admin",
+		"arn:aws-cn:iam::123456789012:user/alice",
+	}, resource, "test:member")
+	require.Len(t, grants, 2)
+	assert.Equal(t, ResourceTypeIAMRole.Id, grants[0].Principal.Id.ResourceType)
+	assert.Equal(t, ResourceTypeIAMUser.Id, grants[1].Principal.Id.ResourceType)
+}
+
+func TestClusterRoleBuilder_GrantsTemplatedSessions(t *testing.T) {
+	const ssoRole = "arn:aws:iam::123456789012:role/AWSReservedSSO_Admin_0123456789abcdef"
+	userSubject := func(name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: k8s.SubjectKindUser, APIGroup: k8s.RBACAPIGroup, Name: name}
+	}
+	registry := newTestClusterRegistry(false,
+		withBindings(&MockClusterRoleBindingProvider{
+			clusterBindings: []rbacv1.ClusterRoleBinding{{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+				Subjects:   []rbacv1.Subject{userSubject("sso:alice@corp.com"), userSubject("sso:bob@corp.com")},
+			}},
+		}),
+		withIdentity(&MockEKSClient{
+			usernameMatches: map[string][]client.UsernameMatch{
+				"sso:alice@corp.com": {{ARN: ssoRole, SessionName: "alice@corp.com"}},
+				"sso:bob@corp.com":   {{ARN: ssoRole, SessionName: "bob@corp.com"}},
+			},
+		}),
+	)
+	builder := NewClusterRoleBuilder(registry, newIdentityMapper(false, nil))
+	resource, err := clusterRoleResource(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin"}})
+	require.NoError(t, err)
+
+	grants, _, _, err := builder.Grants(context.Background(), resource, &pagination.Token{})
+	require.NoError(t, err)
+
+	// Both sessions hold the cluster role through the same role, in a single grant.
+	require.Len(t, grants, 1)
+	assert.Equal(t, ssoRole, grants[0].Principal.Id.Resource)
+
+	metadata := &v2.GrantMetadata{}
+	annos := annotations.Annotations(grants[0].Annotations)
+	ok, err := annos.Pick(metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	sessions := metadata.Metadata.Fields["sessions"].GetListValue().GetValues()
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "alice@corp.com", sessions[0].GetStructValue().Fields["session_name"].GetStringValue())
+	assert.Equal(t, "sso:alice@corp.com", sessions[0].GetStructValue().Fields["kubernetes_username"].GetStringValue())
+	assert.Equal(t, "bob@corp.com", sessions[1].GetStructValue().Fields["session_name"].GetStringValue())
+}
@@ -0,0 +1,223 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+)
+
+// bindingProvider resolves the RoleBindings and ClusterRoleBindings of a single cluster.
+type bindingProvider interface {
+	k8s.ClusterRoleBindingProvider
+	k8s.RoleBindingProvider
+}
+
+// eksCluster holds the clients used to sync a single EKS cluster.
+type eksCluster struct {
+	// id identifies the cluster in scoped resource IDs, formatted as region/name.
+	id             string
+	name           string
+	region         string
+	kube           kubernetes.Interface
+	k8s            *k8s.Kubernetes
+	bindings       bindingProvider
+	eksClient      *client.EKSClient
+	accessPolicies client.AccessPolicyClient
+	accessEntries  client.AccessEntryClient
+	identity       client.IdentityClient
+	podIdentities  client.PodIdentityClient
+	info           client.ClusterClient
+}
+
+// partition returns the AWS partition of the cluster's region.
+func (c *eksCluster) partition() string {
+	return client.PartitionForRegion(c.region)
+}
+
+var errClusterNotFound = errors.New("unknown EKS cluster")
+
+func clusterID(region string, name string) string {
+	return region + "/" + name
+}
+
+// clusterDiscoveryFunc returns the clusters to sync, reusing the known clusters that are still present.
+type clusterDiscoveryFunc func(ctx context.Context, known map[string]*eksCluster) ([]*eksCluster, error)
+
+// clusterRegistry holds every cluster synced by the connector.
+// When scoped is set, cluster-level resource IDs are prefixed with the cluster ID so
+// that resources with the same name in different clusters don't collide. Single cluster
+// deployments keep unscoped IDs so that existing resource IDs remain stable.
+type clusterRegistry struct {
+	mtx      sync.RWMutex
+	clusters []*eksCluster
+	byID     map[string]*eksCluster
+	scoped   bool
+	// discover, when set, refreshes the clusters once refreshAt has passed.
+	discover  clusterDiscoveryFunc
+	refreshAt time.Time
+}
+
+func newClusterRegistry(scoped bool, clusters ...*eksCluster) *clusterRegistry {
+	r := &clusterRegistry{
+		scoped: scoped,
+	}
+	r.set(clusters)
+	return r
+}
+
+// newDiscoveredClusterRegistry returns a scoped registry whose clusters are refreshed by discover.
+func newDiscoveredClusterRegistry(discover clusterDiscoveryFunc) *clusterRegistry {
+	return &clusterRegistry{
+		byID:     make(map[string]*eksCluster),
+		scoped:   true,
+		discover: discover,
+	}
+}
+
+func (r *clusterRegistry) set(clusters []*eksCluster) {
+	byID := make(map[string]*eksCluster, len(clusters))
+	for _, c := range clusters {
+		byID[c.id] = c
+	}
+	r.clusters = clusters
+	r.byID = byID
+}
+
+// refresh rediscovers the clusters when the registry was built by discovery and the previous result expired.
+// New clusters are connected to and deleted clusters are dropped. If discovery fails, the previous clusters are kept.
+func (r *clusterRegistry) refresh(ctx context.Context) error {
+	if r.discover == nil {
+		return nil
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	if now.Before(r.refreshAt) {
+		return nil
+	}
+
+	clusters, err := r.discover(ctx, r.byID)
+	if err != nil {
+		if r.refreshAt.IsZero() {
+			return fmt.Errorf("failed to discover EKS clusters: %w", err)
+		}
+		ctxzap.Extract(ctx).Warn("failed to refresh EKS clusters, keeping previously discovered clusters", zap.Error(err))
+		r.refreshAt = now.Add(cacheTTL)
+		return nil
+	}
+
+	for id := range r.byID {
+		if !slices.ContainsFunc(clusters, func(c *eksCluster) bool { return c.id == id }) {
+			ctxzap.Extract(ctx).Info("EKS cluster no longer discovered, dropping it", zap.String("cluster", id))
+		}
+	}
+	r.set(clusters)
+	r.refreshAt = now.Add(cacheTTL)
+	return nil
+}
+
+// all returns every registered cluster.
+func (r *clusterRegistry) all() []*eksCluster {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.clusters
+}
+
+// get returns the cluster with the given ID.
+func (r *clusterRegistry) get(id string) (*eksCluster, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	c, ok := r.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errClusterNotFound, id)
+	}
+	return c, nil
+}
+
+// scopeID returns the resource ID for a cluster-level object.
+func (r *clusterRegistry) scopeID(c *eksCluster, rawID string) string {
+	if !r.scoped {
+		return rawID
+	}
+	return c.id + "/" + rawID
+}
+
+// scopeResource rewrites the IDs of a cluster-level resource, and of its parent, so they are scoped to the cluster.
+func (r *clusterRegistry) scopeResource(c *eksCluster, resource *v2.Resource) *v2.Resource {
+	resource.Id.Resource = r.scopeID(c, resource.Id.Resource)
+	if resource.ParentResourceId != nil {
+		resource.ParentResourceId.Resource = r.scopeID(c, resource.ParentResourceId.Resource)
+	}
+	return resource
+}
+
+// lookup returns the cluster a scoped resource ID belongs to, along with the unscoped ID.
+func (r *clusterRegistry) lookup(resourceID string) (*eksCluster, string, error) {
+	if !r.scoped {
+		clusters := r.all()
+		if len(clusters) != 1 {
+			return nil, "", fmt.Errorf("expected a single EKS cluster, found %d", len(clusters))
+		}
+		return clusters[0], resourceID, nil
+	}
+
+	// Scoped IDs are formatted as region/name/raw-id.
+	parts := strings.SplitN(resourceID, "/", 3)
+	if len(parts) != 3 {
+		return nil, "", fmt.Errorf("invalid cluster scoped resource ID: %s", resourceID)
+	}
+	c, err := r.get(clusterID(parts[0], parts[1]))
+	if err != nil {
+		return nil, "", err
+	}
+	return c, parts[2], nil
+}
+
+// lookupParent returns the cluster a child resource is listed under, along with the unscoped parent ID.
+// Parents are either an eks_cluster resource or a cluster-level resource such as a namespace.
+// A nil cluster is returned when there is no parent, so that child resource types aren't also listed
+// at the top level, and when the cluster was dropped by a refresh since the sync started.
+func (r *clusterRegistry) lookupParent(parentResourceID *v2.ResourceId) (*eksCluster, string, error) {
+	if parentResourceID == nil {
+		return nil, "", nil
+	}
+
+	var (
+		c     *eksCluster
+		rawID string
+		err   error
+	)
+	if parentResourceID.ResourceType == ResourceTypeEKSCluster.Id {
+		c, err = r.get(parentResourceID.Resource)
+	} else {
+		c, rawID, err = r.lookup(parentResourceID.Resource)
+	}
+	if errors.Is(err, errClusterNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return c, rawID, nil
+}
+
+// clusterResourceID returns the ID of the eks_cluster resource for a cluster.
+func clusterResourceID(c *eksCluster) *v2.ResourceId {
+	return &v2.ResourceId{
+		ResourceType: ResourceTypeEKSCluster.Id,
+		Resource:     c.id,
+	}
+}
@@ -0,0 +1,215 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-eks/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClusterOption sets a client of the test cluster.
+type testClusterOption func(*eksCluster)
+
+func withBindings(bindings bindingProvider) testClusterOption {
+	return func(c *eksCluster) { c.bindings = bindings }
+}
+
+func withAccessPolicies(accessPolicies client.AccessPolicyClient) testClusterOption {
+	return func(c *eksCluster) { c.accessPolicies = accessPolicies }
+}
+
+func withAccessEntries(accessEntries client.AccessEntryClient) testClusterOption {
+	return func(c *eksCluster) { c.accessEntries = accessEntries }
+}
+
+func withIdentity(identity client.IdentityClient) testClusterOption {
+	return func(c *eksCluster) { c.identity = identity }
+}
+
+func withPodIdentities(podIdentities client.PodIdentityClient) testClusterOption {
+	return func(c *eksCluster) { c.podIdentities = podIdentities }
+}
+
+func withInfo(info client.ClusterClient) testClusterOption {
+	return func(c *eksCluster) { c.info = info }
+}
+
+// withEKSClient backs every EKS client of the cluster with the client, as newCluster does.
+func withEKSClient(eksClient *client.EKSClient) testClusterOption {
+	return func(c *eksCluster) {
+		c.eksClient = eksClient
+		c.accessPolicies = eksClient
+		c.accessEntries = eksClient
+		c.identity = eksClient
+		c.podIdentities = eksClient
+		c.info = eksClient
+	}
+}
+
+// newTestCluster returns the us-east-1/test-cluster cluster with the clients set by the options.
+func newTestCluster(opts ...testClusterOption) *eksCluster {
+	cluster := &eksCluster{
+		id:     clusterID("us-east-1", "test-cluster"),
+		name:   "test-cluster",
+		region: "us-east-1",
+	}
+	for _, opt := range opts {
+		opt(cluster)
+	}
+	return cluster
+}
+
+// newTestClusterRegistry returns a registry with a single test cluster, whose clients are set by the options.
+func newTestClusterRegistry(scoped bool, opts ...testClusterOption) *clusterRegistry {
+	return newClusterRegistry(scoped, newTestCluster(opts...))
+}
+
+func TestClusterRegistry_Lookup(t *testing.T) {
+	clusterA := &eksCluster{id: clusterID("us-east-1", "a"), name: "a", region: "us-east-1"}
+	clusterB := &eksCluster{id: clusterID("eu-west-1", "b"), name: "b", region: "eu-west-1"}
+
+	t.Run("unscoped", func(t *testing.T) {
+		registry := newClusterRegistry(false, clusterA)
+
+		id := registry.scopeID(clusterA, "kube-system/admin")
+		assert.Equal(t, "kube-system/admin", id)
+
+		cluster, rawID, err := registry.lookup(id)
+		require.NoError(t, err)
+		assert.Equal(t, clusterA, cluster)
+		assert.Equal(t, "kube-system/admin", rawID)
+	})
+
+	t.Run("scoped", func(t *testing.T) {
+		registry := newClusterRegistry(true, clusterA, clusterB)
+
+		id := registry.scopeID(clusterB, "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy")
+		assert.Equal(t, "eu-west-1/b/arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy", id)
+
+		cluster, rawID, err := registry.lookup(id)
+		require.NoError(t, err)
+		assert.Equal(t, clusterB, cluster)
+		assert.Equal(t, "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy", rawID)
+	})
+
+	t.Run("scoped unknown cluster", func(t *testing.T) {
+		registry := newClusterRegistry(true, clusterA)
+
+		_, _, err := registry.lookup("us-east-1/missing/admin")
+		assert.Error(t, err)
+	})
+
+	t.Run("scoped invalid ID", func(t *testing.T) {
+		registry := newClusterRegistry(true, clusterA)
+
+		_, _, err := registry.lookup("admin")
+		assert.Error(t, err)
+	})
+}
+
+func TestClusterRegistry_ScopeResource(t *testing.T) {
+	cluster := &eksCluster{id: clusterID("us-east-1", "a")}
+	registry := newClusterRegistry(true, cluster)
+
+	resource := &v2.Resource{
+		Id:               &v2.ResourceId{ResourceType: ResourceTypeNamespaceRole.Id, Resource: "default/reader"},
+		ParentResourceId: &v2.ResourceId{ResourceType: "namespace", Resource: "default"},
+	}
+	registry.scopeResource(cluster, resource)
+
+	assert.Equal(t, "us-east-1/a/default/reader", resource.Id.Resource)
+	assert.Equal(t, "us-east-1/a/default", resource.ParentResourceId.Resource)
+}
+
+func TestClusterRegistry_LookupParent(t *testing.T) {
+	clusterA := &eksCluster{id: clusterID("us-east-1", "a")}
+	clusterB := &eksCluster{id: clusterID("us-east-1", "b")}
+	registry := newClusterRegistry(true, clusterA, clusterB)
+
+	// Child resource types aren't listed at the top level.
+	cluster, _, err := registry.lookupParent(nil)
+	require.NoError(t, err)
+	assert.Nil(t, cluster)
+
+	cluster, rawID, err := registry.lookupParent(clusterResourceID(clusterB))
+	require.NoError(t, err)
+	assert.Equal(t, clusterB, cluster)
+	assert.Empty(t, rawID)
+
+	cluster, rawID, err = registry.lookupParent(&v2.ResourceId{ResourceType: "namespace", Resource: "us-east-1/a/default"})
+	require.NoError(t, err)
+	assert.Equal(t, clusterA, cluster)
+	assert.Equal(t, "default", rawID)
+}
+
+func TestClusterRegistry_Refresh(t *testing.T) {
+	clusterA := &eksCluster{id: clusterID("us-east-1", "a")}
+	clusterB := &eksCluster{id: clusterID("eu-west-1", "b")}
+
+	discovered := []*eksCluster{clusterA, clusterB}
+	calls := 0
+	registry := newDiscoveredClusterRegistry(func(ctx context.Context, known map[string]*eksCluster) ([]*eksCluster, error) {
+		calls++
+		return discovered, nil
+	})
+
+	require.NoError(t, registry.refresh(t.Context()))
+	assert.Equal(t, []*eksCluster{clusterA, clusterB}, registry.all())
+
+	// Results are cached until they expire.
+	discovered = []*eksCluster{clusterA}
+	require.NoError(t, registry.refresh(t.Context()))
+	assert.Equal(t, 1, calls)
+
+	registry.refreshAt = time.Now().Add(-time.Second)
+	require.NoError(t, registry.refresh(t.Context()))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []*eksCluster{clusterA}, registry.all())
+
+	_, err := registry.get(clusterB.id)
+	assert.ErrorIs(t, err, errClusterNotFound)
+}
+
+func TestClusterRegistry_RefreshError(t *testing.T) {
+	clusterA := &eksCluster{id: clusterID("us-east-1", "a")}
+	fail := true
+	registry := newDiscoveredClusterRegistry(func(ctx context.Context, known map[string]*eksCluster) ([]*eksCluster, error) {
+		if fail {
+			return nil, assert.AnError
+		}
+		return []*eksCluster{clusterA}, nil
+	})
+
+	// The first discovery has nothing to fall back on.
+	assert.Error(t, registry.refresh(t.Context()))
+
+	fail = false
+	require.NoError(t, registry.refresh(t.Context()))
+
+	// Later failures keep the previously discovered clusters.
+	fail = true
+	registry.refreshAt = time.Now().Add(-time.Second)
+	require.NoError(t, registry.refresh(t.Context()))
+	assert.Equal(t, []*eksCluster{clusterA}, registry.all())
+}
+
+func TestClusterRegistry_LookupParentOfDroppedCluster(t *testing.T) {
+	clusterA := &eksCluster{id: clusterID("us-east-1", "a")}
+	clusterB := &eksCluster{id: clusterID("us-east-1", "b")}
+	registry := newClusterRegistry(true, clusterA, clusterB)
+
+	// Cluster b is dropped before its children are listed.
+	registry.set([]*eksCluster{clusterA})
+
+	cluster, _, err := registry.lookupParent(clusterResourceID(clusterB))
+	require.NoError(t, err)
+	assert.Nil(t, cluster)
+
+	cluster, _, err = registry.lookupParent(&v2.ResourceId{ResourceType: "namespace", Resource: "us-east-1/b/default"})
+	require.NoError(t, err)
+	assert.Nil(t, cluster)
+}
@@ -24,30 +24,47 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
 type Connector struct {
-	k8s                 *k8s.Kubernetes
-	eksClient           *client.EKSClient
-	_onceCallingConfig  map[string]*sync.Once
-	_callingConfig      map[string]awsSdk.Config
-	_callingConfigError map[string]error
-	config              *config.Eks
-	awsConfig           awsSdk.Config
-	baseClient          *http.Client
+	clusters           *clusterRegistry
+	iamService         *client.EKSClient
+	accessPolicyFilter *accessPolicyFilter
+	identities         *identityMapper
+	iamUsers           *iamUserBuilder
+	iamRoleFilter      *iamRoleFilter
+	callingConfigsMtx  sync.Mutex
+	_callingConfigs    map[string]*regionCallingConfig
+	config             *config.Eks
+	awsConfig          awsSdk.Config
+	baseClient         *http.Client
+}
+
+// regionCallingConfig is the calling config of a region, loaded once and shared by every caller.
+type regionCallingConfig struct {
+	once   sync.Once
+	config awsSdk.Config
+	err    error
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
-	var syncers []connectorbuilder.ResourceSyncer
-	if d.k8s != nil {
-		syncers = append(syncers, d.k8s.ResourceSyncers(ctx)...)
+	syncers := []connectorbuilder.ResourceSyncer{
+		NewEKSClusterBuilder(d.clusters),
+		newClusterResourceSyncer(k8s.ResourceTypeConfigMap, d.clusters),
+		NewClusterRoleBuilder(d.clusters, d.identities),
+		newClusterResourceSyncer(k8s.ResourceTypeNamespace, d.clusters, ResourceTypeNamespaceRole),
+		NewRoleBuilder(d.clusters, d.identities),
+		newClusterResourceSyncer(k8s.ResourceTypeServiceAccount, d.clusters),
+		NewAccessPolicyBuilder(d.clusters, d.accessPolicyFilter),
+		NewAccessEntryBuilder(d.clusters),
+		NewKubeGroupBuilder(d.clusters, d.identities),
+		NewIAMRoleBuilder(d.iamService, d.clusters, d.iamRoleFilter),
+	}
+	// IAM users are usually synced by the AWS connector.
+	if d.iamUsers != nil {
+		syncers = append(syncers, d.iamUsers)
 	}
-	syncers = append(syncers,
-		NewAccessPolicyBuilder(d.eksClient),
-		NewIAMRoleBuilder(d.eksClient),
-	)
 	return syncers
 }
 
@@ -65,43 +82,50 @@ func (d *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error)
 	}, nil
 }
 
-// Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
-// to be sure that they are valid.
+// Validate is called to ensure that the connector is properly configured. It checks the AWS credentials,
+// then that every cluster can be described and read through Kubernetes and EKS. Failures that only break
+// provisioning are reported in the returned annotation and logged, without failing validation.
 func (d *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
-	return nil, nil
-}
+	l := ctxzap.Extract(ctx)
 
-// NewDefault returns a credential-free instance of the connector used by the
-// `capabilities` subcommand. The k8s and eksClient fields are stub instances
-// so that ResourceSyncers can enumerate every resource type without making
-// network calls. The custom syncer overrides for Role and ClusterRole match
-// New() so the emitted resource type ids stay consistent (Role is renamed to
-// namespace_role to avoid colliding with the EKS IAMRole id "role").
-func NewDefault(ctx context.Context) *Connector {
-	syncResources := []string{
-		k8s.ResourceTypeConfigMap.Id,
-		k8s.ResourceTypeClusterRole.Id,
-		k8s.ResourceTypeNamespace.Id,
-		k8s.ResourceTypeRole.Id,
-		k8s.ResourceTypeServiceAccount.Id,
+	report, err := d.validate(ctx)
+	if err != nil {
+		return nil, err
 	}
-	syncersMap := map[string]k8s.ResourceSyncerBuilder{
-		k8s.ResourceTypeClusterRole.Id: func(_ *kubernetes.Interface, kb *k8s.Kubernetes) connectorbuilder.ResourceSyncer {
-			return NewClusterRoleBuilder(nil, kb, nil)
-		},
-		k8s.ResourceTypeRole.Id: func(_ *kubernetes.Interface, kb *k8s.Kubernetes) connectorbuilder.ResourceSyncer {
-			return NewRoleBuilder(nil, kb, nil)
-		},
+
+	for _, check := range report.failures(validationScopeProvisioning) {
+		l.Warn("eks-connector: provisioning will fail",
+			zap.String("cluster", check.cluster),
+			zap.String("check", check.name),
+			zap.Error(check.err))
 	}
-	cb, err := k8s.New(ctx, &rest.Config{}, k8s.WithSyncResources(syncResources), k8s.WithCustomSyncers(syncersMap))
+
+	var annos annotations.Annotations
+	summary, err := report.annotation()
 	if err != nil {
-		cb = nil
+		return nil, fmt.Errorf("eks-connector: failed to build validation report: %w", err)
 	}
+	annos.Append(summary)
+
+	if err := report.err(); err != nil {
+		return annos, err
+	}
+	l.Info("eks-connector: validated",
+		zap.String("caller_arn", report.callerARN),
+		zap.Bool("provisioning_ready", report.provisioningReady()))
+	return annos, nil
+}
+
+// NewDefault returns a credential-free instance of the connector used by the
+// `capabilities` subcommand. The cluster registry is empty so that
+// ResourceSyncers can enumerate every resource type without making network
+// calls. Role is exposed as namespace_role to avoid colliding with the EKS
+// IAMRole id "role".
+func NewDefault(ctx context.Context) *Connector {
 	return &Connector{
-		k8s:                 cb,
-		_onceCallingConfig:  map[string]*sync.Once{},
-		_callingConfig:      map[string]awsSdk.Config{},
-		_callingConfigError: map[string]error{},
+		clusters:        newClusterRegistry(false),
+		identities:      newIdentityMapper(false, nil),
+		_callingConfigs: map[string]*regionCallingConfig{},
 	}
 }
 
@@ -132,73 +156,127 @@ func New(
 	}
 
 	newConnector := &Connector{
-		awsConfig:           baseConfig.Copy(),
-		baseClient:          httpClient,
-		config:              cfg,
-		_onceCallingConfig:  map[string]*sync.Once{},
-		_callingConfig:      map[string]awsSdk.Config{},
-		_callingConfigError: map[string]error{},
+		awsConfig:          baseConfig.Copy(),
+		baseClient:         httpClient,
+		config:             cfg,
+		accessPolicyFilter: newAccessPolicyFilter(cfg.EksRequestableAccessPolicies, cfg.EksNonRequestableAccessPolicies),
+		_callingConfigs:    map[string]*regionCallingConfig{},
 	}
 
-	connectorOpts := k8s.WithSyncResources([]string{
-		k8s.ResourceTypeConfigMap.Id,
-		k8s.ResourceTypeClusterRole.Id,
-		k8s.ResourceTypeNamespace.Id,
-		k8s.ResourceTypeRole.Id,
-		k8s.ResourceTypeServiceAccount.Id,
-	})
-
 	iamClient, eksSDKClient, err := newConnector.SetupClients(ctx)
 	if err != nil {
 		l.Error("error creating EKS client", zap.Error(err))
 		return nil, err
 	}
+	newConnector.iamService = client.NewIAMClient(iamClient)
+	newConnector.identities = newIdentityMapper(cfg.EksPreferAccessEntries, newConnector.iamService)
+
+	// A single eks-cluster-name keeps unscoped resource IDs, any other selection scopes them by cluster.
+	if cfg.EksSyncAllClusters {
+		filter, err := newClusterFilter(cfg.EksClusterNamePatterns, cfg.EksClusterTags)
+		if err != nil {
+			return nil, err
+		}
+		regions := cfg.EksDiscoveryRegions
+		if len(regions) == 0 {
+			regions = []string{cfg.EksRegion}
+		}
+		newConnector.clusters = newDiscoveredClusterRegistry(func(ctx context.Context, known map[string]*eksCluster) ([]*eksCluster, error) {
+			return newConnector.discoverClusters(ctx, iamClient, regions, filter, known)
+		})
+		// Discover the clusters up front so that configuration errors surface on startup.
+		err = newConnector.clusters.refresh(ctx)
+		if err != nil {
+			l.Error("failed to discover EKS clusters", zap.Error(err))
+			return nil, err
+		}
+	} else {
+		clusterNames := []string{cfg.EksClusterName}
+		scoped := false
+		if len(cfg.EksClusterNames) > 0 {
+			clusterNames = cfg.EksClusterNames
+			scoped = true
+		}
+
+		clusters := make([]*eksCluster, 0, len(clusterNames))
+		for _, name := range clusterNames {
+			// Get EKS cluster configuration
+			eksCfg, err := getEKSClusterCfg(ctx, eksSDKClient, cfg.EksRegion, name)
+			if err != nil {
+				l.Error("failed to get EKS cluster config", zap.String("cluster", name), zap.Error(err))
+				return nil, err
+			}
+			cluster, err := newConnector.newCluster(ctx, iamClient, eksSDKClient, eksCfg)
+			if err != nil {
+				l.Error("failed to connect to EKS cluster", zap.String("cluster", name), zap.Error(err))
+				return nil, err
+			}
+			clusters = append(clusters, cluster)
+		}
+		newConnector.clusters = newClusterRegistry(scoped, clusters...)
+	}
 
-	// Get EKS cluster configuration
-	eksCfg, err := getEKSClusterCfg(ctx, eksSDKClient, cfg.EksRegion, cfg.EksClusterName)
+	newConnector.iamRoleFilter, err = newIAMRoleFilter(cfg.EksIamRolePathPrefixes, cfg.EksIamRoleTags, cfg.EksRelevantIamRolesOnly)
 	if err != nil {
-		l.Error("failed to get EKS cluster config", zap.Error(err))
 		return nil, err
 	}
+	if cfg.EksSyncIamUsers {
+		newConnector.iamUsers = NewIAMUserBuilder(newConnector.iamService, newConnector.clusters, cfg.EksReferencedIamUsersOnly)
+	}
+	return newConnector, nil
+}
+
+// newCluster builds the Kubernetes and EKS clients for a single cluster.
+func (d *Connector) newCluster(ctx context.Context, iamClient *iam.Client, eksSDKClient *eks.Client, eksCfg *client.EKSConfig) (*eksCluster, error) {
+	region, name := eksCfg.Region, eksCfg.ClusterName
 
 	// Get the AWS config with assumed role credentials for token generation
-	callingConfig, err := newConnector.getCallingConfig(ctx, cfg.EksRegion)
+	callingConfig, err := d.getCallingConfig(ctx, region)
 	if err != nil {
-		l.Error("failed to get calling config", zap.Error(err))
-		return nil, err
+		return nil, fmt.Errorf("failed to get calling config: %w", err)
 	}
 
 	restConfig, err := newKubernetesConfig(ctx, eksCfg, callingConfig)
 	if err != nil {
-		l.Error("failed to create Kubernetes config", zap.Error(err))
-		return nil, err
+		return nil, fmt.Errorf("failed to create Kubernetes config: %w", err)
 	}
 
-	eksClient, err := client.NewEKSClient(restConfig, iamClient, eksSDKClient, cfg.EksClusterName)
+	eksClient, err := client.NewEKSClient(restConfig, iamClient, eksSDKClient, name, d.config.EksAccessEntryConcurrency)
 	if err != nil {
-		l.Error("error creating EKS client", zap.Error(err))
-		return nil, err
+		return nil, fmt.Errorf("error creating EKS client: %w", err)
 	}
 
-	syncersMap := make(map[string]k8s.ResourceSyncerBuilder)
-	syncersMap[k8s.ResourceTypeClusterRole.Id] = func(client *kubernetes.Interface, k *k8s.Kubernetes) connectorbuilder.ResourceSyncer {
-		return NewClusterRoleBuilder(*client, k, eksClient)
-	}
-	syncersMap[k8s.ResourceTypeRole.Id] = func(client *kubernetes.Interface, k *k8s.Kubernetes) connectorbuilder.ResourceSyncer {
-		return NewRoleBuilder(*client, k, eksClient)
+	kube, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating kubernetes client: %w", err)
 	}
 
-	customSyncerOpt := k8s.WithCustomSyncers(syncersMap)
-
-	cb, err := k8s.New(ctx, restConfig, connectorOpts, customSyncerOpt)
+	// ClusterRoles and Roles are synced by the EKS builders, the remaining types by the Kubernetes connector.
+	// Kubernetes groups are wrapped by the EKS builder, which adds their IAM members.
+	cb, err := k8s.New(ctx, restConfig, k8s.WithSyncResources([]string{
+		k8s.ResourceTypeConfigMap.Id,
+		k8s.ResourceTypeNamespace.Id,
+		k8s.ResourceTypeServiceAccount.Id,
+		k8s.ResourceTypeKubeGroup.Id,
+	}))
 	if err != nil {
-		l.Error("error creating k8s connector", zap.Error(err))
-		return nil, err
+		return nil, fmt.Errorf("error creating k8s connector: %w", err)
 	}
 
-	newConnector.k8s = cb
-	newConnector.eksClient = eksClient
-	return newConnector, nil
+	return &eksCluster{
+		id:             clusterID(region, name),
+		name:           name,
+		region:         region,
+		kube:           kube,
+		k8s:            cb,
+		bindings:       cb,
+		eksClient:      eksClient,
+		accessPolicies: eksClient,
+		accessEntries:  eksClient,
+		identity:       eksClient,
+		podIdentities:  eksClient,
+		info:           eksClient,
+	}, nil
 }
 
 func GetAwsConfigOptions(httpClient *http.Client, cfg *config.Eks) []func(*awsConfig.LoadOptions) error {
@@ -224,12 +302,19 @@ func GetAwsConfigOptions(httpClient *http.Client, cfg *config.Eks) []func(*awsCo
 	return opts
 }
 
+// getCallingConfig returns the calling config of the region. It's safe for concurrent use: the config of each
+// region is loaded once, while other regions load independently.
 func (o *Connector) getCallingConfig(ctx context.Context, region string) (awsSdk.Config, error) {
-	if _, ok := o._onceCallingConfig[region]; !ok {
-		o._onceCallingConfig[region] = new(sync.Once)
+	o.callingConfigsMtx.Lock()
+	cached, ok := o._callingConfigs[region]
+	if !ok {
+		cached = &regionCallingConfig{}
+		o._callingConfigs[region] = cached
 	}
-	o._onceCallingConfig[region].Do(func() {
-		o._callingConfig[region], o._callingConfigError[region] = func() (awsSdk.Config, error) {
+	o.callingConfigsMtx.Unlock()
+
+	cached.once.Do(func() {
+		cached.config, cached.err = func() (awsSdk.Config, error) {
 			if o.config.ExternalId == "" {
 				return o.awsConfig, nil
 			}
@@ -277,7 +362,7 @@ func (o *Connector) getCallingConfig(ctx context.Context, region string) (awsSdk
 			return callingConfig, nil
 		}()
 	})
-	return o._callingConfig[region], o._callingConfigError[region]
+	return cached.config, cached.err
 }
 
 func (c *Connector) SetupClients(ctx context.Context) (*iam.Client, *eks.Client, error) {
@@ -0,0 +1,36 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/conductorone/baton-eks/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnector_GetCallingConfigConcurrent(t *testing.T) {
+	connector := &Connector{
+		config:          &config.Eks{},
+		awsConfig:       awsSdk.Config{Region: "us-east-1"},
+		_callingConfigs: map[string]*regionCallingConfig{},
+	}
+
+	// Discovery loads the calling configs of several regions at once.
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			callingConfig, err := connector.getCallingConfig(context.Background(), region)
+			assert.NoError(t, err)
+			assert.Equal(t, "us-east-1", callingConfig.Region)
+		}(fmt.Sprintf("region-%d", i%4))
+	}
+	wg.Wait()
+
+	require.Len(t, connector._callingConfigs, 4)
+}
@@ -0,0 +1,187 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// tagFilter requires a tag, optionally with a specific value.
+type tagFilter struct {
+	key      string
+	value    string
+	anyValue bool
+}
+
+// parseTagFilters parses key=value (or key) tag filters.
+func parseTagFilters(kind string, tags []string) ([]tagFilter, error) {
+	var filters []tagFilter
+	for _, tag := range tags {
+		key, value, hasValue := strings.Cut(tag, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid %s tag filter %q: missing key", kind, tag)
+		}
+		filters = append(filters, tagFilter{
+			key:      key,
+			value:    strings.TrimSpace(value),
+			anyValue: !hasValue,
+		})
+	}
+	return filters, nil
+}
+
+// matchTags reports whether the tags include every required tag.
+func matchTags(filters []tagFilter, tags map[string]string) bool {
+	for _, filter := range filters {
+		value, ok := tags[filter.key]
+		if !ok || (!filter.anyValue && value != filter.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// clusterFilter selects which discovered clusters are synced.
+type clusterFilter struct {
+	namePatterns []string
+	tags         []tagFilter
+}
+
+// newClusterFilter parses the name glob patterns and the key=value (or key) tag filters.
+func newClusterFilter(namePatterns []string, tags []string) (*clusterFilter, error) {
+	for _, pattern := range namePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid cluster name pattern %q: %w", pattern, err)
+		}
+	}
+
+	tagFilters, err := parseTagFilters("cluster", tags)
+	if err != nil {
+		return nil, err
+	}
+	return &clusterFilter{namePatterns: namePatterns, tags: tagFilters}, nil
+}
+
+// matchesName reports whether the cluster name matches any of the patterns. No patterns match every name.
+func (f *clusterFilter) matchesName(name string) bool {
+	if len(f.namePatterns) == 0 {
+		return true
+	}
+	for _, pattern := range f.namePatterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTags reports whether the cluster has every required tag.
+func (f *clusterFilter) matchesTags(tags map[string]string) bool {
+	return matchTags(f.tags, tags)
+}
+
+// regionalEKSClient returns an EKS client for the given region.
+func (d *Connector) regionalEKSClient(ctx context.Context, region string) (*eks.Client, error) {
+	callingConfig, err := d.getCallingConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	regionalConfig := callingConfig.Copy()
+	regionalConfig.Region = region
+	return eks.NewFromConfig(regionalConfig), nil
+}
+
+// discoverClusters lists the clusters of every region and connects to the ones matching the filter.
+// Clusters that are already known are reused. A cluster that can't be connected to is skipped
+// so that it doesn't block the rest of the fleet from syncing. Likewise, a region whose clusters can't be
+// listed is skipped, keeping the clusters already known in it; discovery only fails when every region fails.
+func (d *Connector) discoverClusters(
+	ctx context.Context,
+	iamClient *iam.Client,
+	regions []string,
+	filter *clusterFilter,
+	known map[string]*eksCluster,
+) ([]*eksCluster, error) {
+	l := ctxzap.Extract(ctx)
+
+	var clusters []*eksCluster
+	var regionErrs []error
+	for _, region := range regions {
+		eksSDKClient, names, err := d.listRegionClusterNames(ctx, region)
+		if err != nil {
+			l.Warn("skipping EKS region", zap.String("region", region), zap.Error(err))
+			regionErrs = append(regionErrs, fmt.Errorf("region %s: %w", region, err))
+			clusters = append(clusters, knownClustersInRegion(known, region)...)
+			continue
+		}
+
+		for _, name := range names {
+			if !filter.matchesName(name) {
+				continue
+			}
+
+			eksCfg, err := getEKSClusterCfg(ctx, eksSDKClient, region, name)
+			if err != nil {
+				l.Warn("skipping EKS cluster", zap.String("region", region), zap.String("cluster", name), zap.Error(err))
+				continue
+			}
+			if !filter.matchesTags(eksCfg.Tags) {
+				continue
+			}
+
+			if cluster, ok := known[clusterID(region, name)]; ok {
+				clusters = append(clusters, cluster)
+				continue
+			}
+
+			cluster, err := d.newCluster(ctx, iamClient, eksSDKClient, eksCfg)
+			if err != nil {
+				l.Warn("skipping EKS cluster", zap.String("region", region), zap.String("cluster", name), zap.Error(err))
+				continue
+			}
+			l.Info("discovered EKS cluster", zap.String("cluster", cluster.id))
+			clusters = append(clusters, cluster)
+		}
+	}
+	if len(regions) > 0 && len(regionErrs) == len(regions) {
+		return nil, fmt.Errorf("failed to list EKS clusters of every region: %w", errors.Join(regionErrs...))
+	}
+	return clusters, nil
+}
+
+// listRegionClusterNames returns an EKS client for the region and the names of its clusters.
+func (d *Connector) listRegionClusterNames(ctx context.Context, region string) (*eks.Client, []string, error) {
+	eksSDKClient, err := d.regionalEKSClient(ctx, region)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create EKS client: %w", err)
+	}
+	names, err := listEKSClusterNames(ctx, eksSDKClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return eksSDKClient, names, nil
+}
+
+// knownClustersInRegion returns the known clusters of the region, sorted by ID, so that they're kept
+// while the region can't be listed.
+func knownClustersInRegion(known map[string]*eksCluster, region string) []*eksCluster {
+	var clusters []*eksCluster
+	for _, cluster := range known {
+		if cluster.region == region {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].id < clusters[j].id
+	})
+	return clusters
+}
@@ -0,0 +1,110 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/conductorone/baton-eks/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClusterFilter(t *testing.T) {
+	_, err := newClusterFilter([]string{"prod-["}, nil)
+	assert.Error(t, err)
+
+	_, err = newClusterFilter(nil, []string{"=value"})
+	assert.Error(t, err)
+
+	filter, err := newClusterFilter([]string{"prod-*"}, []string{"team=platform", "managed"})
+	require.NoError(t, err)
+	assert.Equal(t, []tagFilter{
+		{key: "team", value: "platform"},
+		{key: "managed", anyValue: true},
+	}, filter.tags)
+}
+
+func TestClusterFilter_MatchesName(t *testing.T) {
+	filter, err := newClusterFilter([]string{"prod-*", "shared"}, nil)
+	require.NoError(t, err)
+
+	assert.True(t, filter.matchesName("prod-us"))
+	assert.True(t, filter.matchesName("shared"))
+	assert.False(t, filter.matchesName("staging-us"))
+
+	noPatterns, err := newClusterFilter(nil, nil)
+	require.NoError(t, err)
+	assert.True(t, noPatterns.matchesName("anything"))
+}
+
+func TestClusterFilter_MatchesTags(t *testing.T) {
+	filter, err := newClusterFilter(nil, []string{"team=platform", "managed"})
+	require.NoError(t, err)
+
+	assert.True(t, filter.matchesTags(map[string]string{"team": "platform", "managed": ""}))
+	assert.False(t, filter.matchesTags(map[string]string{"team": "data", "managed": "true"}))
+	assert.False(t, filter.matchesTags(map[string]string{"team": "platform"}))
+	assert.False(t, filter.matchesTags(nil))
+}
+
+// regionalEKSTransport serves the EKS ListClusters calls of each region: an empty list for the regions in
+// available, and an access denied error for the others.
+type regionalEKSTransport struct {
+	available map[string]bool
+}
+
+func (r *regionalEKSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The regional endpoints are eks.<region>.amazonaws.com.
+	region := strings.Split(req.URL.Host, ".")[1]
+	response := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"clusters": []}`)),
+		Request:    req,
+	}
+	if !r.available[region] {
+		response.StatusCode = http.StatusForbidden
+		response.Header.Set("X-Amzn-Errortype", "AccessDeniedException")
+		response.Body = io.NopCloser(strings.NewReader(`{"message": "region is disabled"}`))
+	}
+	return response, nil
+}
+
+func newRegionalDiscoveryConnector(available ...string) *Connector {
+	transport := &regionalEKSTransport{available: map[string]bool{}}
+	for _, region := range available {
+		transport.available[region] = true
+	}
+	return &Connector{
+		config: &config.Eks{},
+		awsConfig: awsSdk.Config{
+			Region:      "us-east-1",
+			Credentials: awsSdk.AnonymousCredentials{},
+			HTTPClient:  &http.Client{Transport: transport},
+		},
+		_callingConfigs: map[string]*regionCallingConfig{},
+	}
+}
+
+func TestConnector_DiscoverClustersSkipsFailingRegion(t *testing.T) {
+	connector := newRegionalDiscoveryConnector("us-east-1")
+	filter, err := newClusterFilter(nil, nil)
+	require.NoError(t, err)
+
+	// The clusters already known in the failing region are kept.
+	known := &eksCluster{id: clusterID("eu-west-1", "prod"), name: "prod", region: "eu-west-1"}
+	clusters, err := connector.discoverClusters(context.Background(), nil, []string{"us-east-1", "eu-west-1"}, filter,
+		map[string]*eksCluster{known.id: known})
+	require.NoError(t, err)
+	assert.Equal(t, []*eksCluster{known}, clusters)
+
+	// Discovery fails when no region can be listed.
+	_, err = connector.discoverClusters(context.Background(), nil, []string{"eu-west-1", "ap-south-1"}, filter, nil)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "region eu-west-1")
+	assert.ErrorContains(t, err, "region ap-south-1")
+}
@@ -0,0 +1,154 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// eksClusterChildResourceTypes are the resource types listed as children of an EKS cluster.
+var eksClusterChildResourceTypes = []*v2.ResourceType{
+	k8s.ResourceTypeClusterRole,
+	k8s.ResourceTypeNamespace,
+	k8s.ResourceTypeConfigMap,
+	ResourceTypeAccessPolicy,
+	ResourceTypeAccessEntry,
+	k8s.ResourceTypeKubeGroup,
+}
+
+// eksClusterBuilder syncs the EKS clusters as the parents of every cluster-level resource.
+type eksClusterBuilder struct {
+	clusters *clusterRegistry
+}
+
+// ResourceType returns the resource type for EKS clusters.
+func (e *eksClusterBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return ResourceTypeEKSCluster
+}
+
+// List returns a resource for every synced cluster, refreshing discovered clusters first.
+func (e *eksClusterBuilder) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	err := e.clusters.refresh(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Resource
+	for _, c := range e.clusters.all() {
+		// A cluster that can't be described is still synced so that its children are, with a partial profile.
+		var description *eksTypes.Cluster
+		if c.info != nil {
+			description, err = c.info.DescribeCluster(ctx)
+			if err != nil {
+				l.Warn("failed to describe EKS cluster", zap.String("cluster", c.id), zap.Error(err))
+			}
+		}
+
+		resource, err := eksClusterResource(c, description)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, resource)
+	}
+
+	return rv, "", nil, nil
+}
+
+// eksClusterResource creates a Baton resource from an EKS cluster and its description.
+func eksClusterResource(c *eksCluster, description *eksTypes.Cluster) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name":   c.name,
+		"region": c.region,
+	}
+
+	if description != nil {
+		profile["status"] = string(description.Status)
+		if description.Arn != nil {
+			profile["arn"] = *description.Arn
+		}
+		if description.Version != nil {
+			profile["version"] = *description.Version
+		}
+		if description.PlatformVersion != nil {
+			profile["platform_version"] = *description.PlatformVersion
+		}
+		if description.CreatedAt != nil {
+			profile["created_at"] = description.CreatedAt.Format(time.RFC3339)
+		}
+		if description.ResourcesVpcConfig != nil {
+			profile["endpoint_public_access"] = description.ResourcesVpcConfig.EndpointPublicAccess
+			profile["endpoint_private_access"] = description.ResourcesVpcConfig.EndpointPrivateAccess
+			profile["public_access_cidrs"] = strings.Join(description.ResourcesVpcConfig.PublicAccessCidrs, ",")
+		}
+		if description.AccessConfig != nil {
+			profile["authentication_mode"] = string(description.AccessConfig.AuthenticationMode)
+		}
+		if description.Identity != nil && description.Identity.Oidc != nil && description.Identity.Oidc.Issuer != nil {
+			profile["oidc_issuer"] = *description.Identity.Oidc.Issuer
+		}
+		if description.Logging != nil {
+			profile["enabled_log_types"] = strings.Join(enabledLogTypes(description.Logging), ",")
+		}
+	}
+
+	opts := []rs.ResourceOption{}
+	for _, child := range eksClusterChildResourceTypes {
+		opts = append(opts, rs.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: child.Id}))
+	}
+
+	resource, err := rs.NewAppResource(
+		c.name,
+		ResourceTypeEKSCluster,
+		c.id,
+		[]rs.AppTraitOption{rs.WithAppProfile(profile)},
+		opts...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster resource: %w", err)
+	}
+
+	return resource, nil
+}
+
+// enabledLogTypes returns the control plane log types that are enabled.
+func enabledLogTypes(logging *eksTypes.Logging) []string {
+	var types []string
+	for _, setup := range logging.ClusterLogging {
+		if setup.Enabled == nil || !*setup.Enabled {
+			continue
+		}
+		for _, logType := range setup.Types {
+			types = append(types, string(logType))
+		}
+	}
+	return types
+}
+
+// Entitlements returns no entitlements, access is granted on the cluster's child resources.
+func (e *eksClusterBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns no grants for EKS clusters.
+func (e *eksClusterBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// NewEKSClusterBuilder creates a new EKS cluster builder.
+func NewEKSClusterBuilder(clusters *clusterRegistry) *eksClusterBuilder {
+	return &eksClusterBuilder{
+		clusters: clusters,
+	}
+}
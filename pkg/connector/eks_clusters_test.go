@@ -0,0 +1,72 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEKSClusterResource(t *testing.T) {
+	cluster := &eksCluster{id: clusterID("us-east-1", "prod"), name: "prod", region: "us-east-1"}
+	description := &eksTypes.Cluster{
+		Arn:             aws.String("arn:aws:eks:us-east-1:123456789012:cluster/prod"),
+		Version:         aws.String("1.31"),
+		PlatformVersion: aws.String("eks.12"),
+		Status:          eksTypes.ClusterStatusActive,
+		ResourcesVpcConfig: &eksTypes.VpcConfigResponse{
+			EndpointPublicAccess:  true,
+			EndpointPrivateAccess: false,
+			PublicAccessCidrs:     []string{"10.0.0.0/8", "192.168.0.0/16"},
+		},
+		AccessConfig: &eksTypes.AccessConfigResponse{
+			AuthenticationMode: eksTypes.AuthenticationModeApiAndConfigMap,
+		},
+		Identity: &eksTypes.Identity{
+			Oidc: &eksTypes.OIDC{Issuer: aws.String("https://oidc.eks.us-east-1.amazonaws.com/id/ABC")},
+		},
+		Logging: &eksTypes.Logging{
+			ClusterLogging: []eksTypes.LogSetup{
+				{Enabled: aws.Bool(true), Types: []eksTypes.LogType{eksTypes.LogTypeApi, eksTypes.LogTypeAudit}},
+				{Enabled: aws.Bool(false), Types: []eksTypes.LogType{eksTypes.LogTypeScheduler}},
+			},
+		},
+	}
+
+	resource, err := eksClusterResource(cluster, description)
+	require.NoError(t, err)
+
+	assert.Equal(t, ResourceTypeEKSCluster.Id, resource.Id.ResourceType)
+	assert.Equal(t, "us-east-1/prod", resource.Id.Resource)
+	assert.Equal(t, "prod", resource.DisplayName)
+
+	annos := annotations.Annotations(resource.Annotations)
+	assert.True(t, annos.Contains(&v2.ChildResourceType{}))
+
+	appTrait := &v2.AppTrait{}
+	ok, err := annos.Pick(appTrait)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	profile := appTrait.Profile.AsMap()
+	assert.Equal(t, "1.31", profile["version"])
+	assert.Equal(t, "eks.12", profile["platform_version"])
+	assert.Equal(t, true, profile["endpoint_public_access"])
+	assert.Equal(t, false, profile["endpoint_private_access"])
+	assert.Equal(t, "10.0.0.0/8,192.168.0.0/16", profile["public_access_cidrs"])
+	assert.Equal(t, "API_AND_CONFIG_MAP", profile["authentication_mode"])
+	assert.Equal(t, "https://oidc.eks.us-east-1.amazonaws.com/id/ABC", profile["oidc_issuer"])
+	assert.Equal(t, "api,audit", profile["enabled_log_types"])
+}
+
+func TestEKSClusterResource_WithoutDescription(t *testing.T) {
+	cluster := &eksCluster{id: clusterID("us-east-1", "prod"), name: "prod", region: "us-east-1"}
+
+	resource, err := eksClusterResource(cluster, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1/prod", resource.Id.Resource)
+}
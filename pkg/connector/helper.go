@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -21,14 +22,14 @@ import (
 	"k8s.io/client-go/rest"
 )
 
-func processGrants(matchingARNs []string, resource *v2.Resource, entID string) []*v2.Grant {
+func processGrants(matchingARNs []string, resource *v2.Resource, entID string, opts ...grant.GrantOption) []*v2.Grant {
 	var rv []*v2.Grant
 	if len(matchingARNs) > 0 {
 		// Multiple users can be mapped to the same group.
 		for _, principalARN := range matchingARNs {
-			var grantOpts []grant.GrantOption
+			grantOpts := slices.Clone(opts)
 			resourceType := ResourceTypeIAMUser
-			if strings.Contains(principalARN, ":role/") {
+			if client.IsIAMRoleARN(principalARN) {
 				resourceType = ResourceTypeIAMRole
 				grantExpandable := &v2.GrantExpandable{
 					EntitlementIds: []string{
@@ -54,6 +55,74 @@ func processGrants(matchingARNs []string, resource *v2.Resource, entID string) [
 	return rv
 }
 
+// usernameGrants collects the IAM principals of the User subjects of bindings, so that each principal gets
+// a single grant per entitlement. Roles matched by a templated username, such as sso:{{SessionName}}, may be
+// matched for several sessions, which are all listed in the grant metadata.
+type usernameGrants struct {
+	resource   *v2.Resource
+	principals []usernameGrantKey
+	sessions   map[usernameGrantKey][]interface{}
+}
+
+type usernameGrantKey struct {
+	entitlement  string
+	principalARN string
+}
+
+func newUsernameGrants(resource *v2.Resource) *usernameGrants {
+	return &usernameGrants{
+		resource: resource,
+		sessions: make(map[usernameGrantKey][]interface{}),
+	}
+}
+
+// add records the principals mapped to the username as holding the entitlement.
+func (u *usernameGrants) add(entID string, username string, matches []client.UsernameMatch) {
+	for _, match := range matches {
+		key := usernameGrantKey{entitlement: entID, principalARN: match.ARN}
+		sessions, ok := u.sessions[key]
+		if !ok {
+			u.principals = append(u.principals, key)
+		}
+		if match.SessionName != "" {
+			sessions = append(sessions, map[string]interface{}{
+				"kubernetes_username": username,
+				"session_name":        match.SessionName,
+			})
+		}
+		u.sessions[key] = sessions
+	}
+}
+
+// grants returns a grant for each principal and entitlement, in the order they were added.
+func (u *usernameGrants) grants() []*v2.Grant {
+	var rv []*v2.Grant
+	for _, key := range u.principals {
+		var opts []grant.GrantOption
+		if sessions := u.sessions[key]; len(sessions) > 0 {
+			opts = append(opts, grant.WithGrantMetadata(map[string]interface{}{
+				"sessions": sessions,
+			}))
+		}
+		rv = append(rv, processGrants([]string{key.principalARN}, u.resource, key.entitlement, opts...)...)
+	}
+	return rv
+}
+
+// listEKSClusterNames returns the names of every EKS cluster visible to the client.
+func listEKSClusterNames(ctx context.Context, eksClient *eks.Client) ([]string, error) {
+	var names []string
+	paginator := eks.NewListClustersPaginator(eksClient, &eks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list EKS clusters: %w", err)
+		}
+		names = append(names, page.Clusters...)
+	}
+	return names, nil
+}
+
 // getEKSClusterConfig retrieves the EKS cluster details.
 func getEKSClusterCfg(ctx context.Context, eksClient *eks.Client, region string, clusterName string) (*client.EKSConfig, error) {
 	l := ctxzap.Extract(ctx)
@@ -73,6 +142,10 @@ func getEKSClusterCfg(ctx context.Context, eksClient *eks.Client, region string,
 	if result.Cluster == nil {
 		return nil, fmt.Errorf("EKS cluster %s not found", clusterName)
 	}
+	// Clusters that are still being created have no endpoint or certificate yet.
+	if result.Cluster.Endpoint == nil || result.Cluster.CertificateAuthority == nil || result.Cluster.CertificateAuthority.Data == nil {
+		return nil, fmt.Errorf("EKS cluster %s is not ready, status %s", clusterName, result.Cluster.Status)
+	}
 	caData, err := base64.StdEncoding.DecodeString(*result.Cluster.CertificateAuthority.Data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode CA data: %w", err)
@@ -89,6 +162,7 @@ func getEKSClusterCfg(ctx context.Context, eksClient *eks.Client, region string,
 		Region:            region,
 		Endpoint:          *result.Cluster.Endpoint,
 		CAData:            caData,
+		Tags:              result.Cluster.Tags,
 	}, nil
 }
 
@@ -109,7 +183,7 @@ func newKubernetesConfig(ctx context.Context, eksCfg *client.EKSConfig, awsConfi
 
 	return &rest.Config{
 		Host:        eksCfg.Endpoint,
-		BearerToken: token,
+		BearerToken: token.Token,
 		TLSClientConfig: rest.TLSClientConfig{
 			CAData:     eksCfg.CAData,
 			ServerName: eksCfg.ClusterServerName,
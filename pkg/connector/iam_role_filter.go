@@ -0,0 +1,171 @@
+package connector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/conductorone/baton-eks/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// iamRoleFilter selects which IAM roles are synced.
+type iamRoleFilter struct {
+	pathPrefixes []string
+	tags         []tagFilter
+	// relevantOnly limits the sync to the roles that are relevant to the clusters, see expandRelevantRoles.
+	relevantOnly bool
+}
+
+// newIAMRoleFilter parses the path prefixes and the key=value (or key) tag filters.
+func newIAMRoleFilter(pathPrefixes []string, tags []string, relevantOnly bool) (*iamRoleFilter, error) {
+	tagFilters, err := parseTagFilters("IAM role", tags)
+	if err != nil {
+		return nil, err
+	}
+	return &iamRoleFilter{
+		pathPrefixes: pathPrefixes,
+		tags:         tagFilters,
+		relevantOnly: relevantOnly,
+	}, nil
+}
+
+// matches reports whether the role's path starts with any of the prefixes and the role has every required tag.
+// A nil filter matches every role.
+func (f *iamRoleFilter) matches(role *client.IAMRole) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.pathPrefixes) > 0 {
+		rolePath := "/"
+		if role.Path != nil {
+			rolePath = *role.Path
+		}
+		matched := false
+		for _, prefix := range f.pathPrefixes {
+			if strings.HasPrefix(rolePath, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return matchTags(f.tags, role.Tags)
+}
+
+// relevantRoles returns the keys of the roles that are relevant to the clusters, see roleKey.
+// They are collected again at the start of each sync.
+func (i *iamRoleBuilder) relevantRoles(ctx context.Context, refresh bool) (map[string]bool, error) {
+	i.relevantMtx.Lock()
+	defer i.relevantMtx.Unlock()
+
+	if i.relevant != nil && !refresh {
+		return i.relevant, nil
+	}
+
+	referenced, issuers := i.clusterRoleReferences(ctx)
+	trusts, err := i.eksClient.ListRoleTrusts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i.relevant = expandRelevantRoles(referenced, issuers, trusts)
+	return i.relevant, nil
+}
+
+// clusterRoleReferences returns the ARNs of the roles the clusters reference through aws-auth, access entries,
+// service account annotations or Pod Identity associations, and the OIDC issuers of the clusters.
+func (i *iamRoleBuilder) clusterRoleReferences(ctx context.Context) (map[string]bool, map[string]bool) {
+	l := ctxzap.Extract(ctx)
+	referenced := make(map[string]bool)
+	issuers := make(map[string]bool)
+
+	for _, cluster := range i.irsa.clusters.all() {
+		principals, err := cluster.identity.ListMappedPrincipals(ctx)
+		if err != nil {
+			l.Warn("failed to list IAM principals mapped in cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+		}
+		for _, principal := range principals {
+			if client.IsIAMRoleARN(principal) {
+				referenced[principal] = true
+			}
+		}
+
+		serviceAccounts, err := i.irsa.serviceAccounts(ctx, cluster)
+		if err != nil {
+			l.Warn("failed to get service accounts of cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+		} else {
+			if serviceAccounts.issuer != "" {
+				issuers[serviceAccounts.issuer] = true
+			}
+			for _, sa := range serviceAccounts.serviceAccounts {
+				if roleARN := sa.Annotations[irsaRoleARNAnnotation]; roleARN != "" {
+					referenced[roleARN] = true
+				}
+			}
+		}
+
+		associations, err := i.podIdentity.associations(ctx, cluster)
+		if err != nil {
+			l.Warn("failed to list pod identity associations of cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+			continue
+		}
+		for roleARN := range associations.byRole {
+			referenced[roleARN] = true
+		}
+	}
+	return referenced, issuers
+}
+
+// expandRelevantRoles returns the keys of the referenced roles, of the roles that trust the OIDC issuer of a cluster,
+// and of the roles that can assume any of them, directly or through other roles.
+func expandRelevantRoles(referenced map[string]bool, issuers map[string]bool, trusts map[string]*client.RoleTrust) map[string]bool {
+	relevant := make(map[string]bool)
+	for roleARN := range referenced {
+		relevant[roleKey(roleARN)] = true
+	}
+
+	// assumers are the roles that can assume each role, by role key.
+	assumers := make(map[string][]string)
+	for roleARN, trust := range trusts {
+		for _, webIdentity := range trust.WebIdentities {
+			if issuers[webIdentity.Issuer] {
+				relevant[roleKey(roleARN)] = true
+			}
+		}
+		for _, principal := range trust.Principals {
+			if client.IsIAMRoleARN(principal.ARN) {
+				assumers[roleKey(roleARN)] = append(assumers[roleKey(roleARN)], roleKey(principal.ARN))
+			}
+		}
+	}
+
+	queue := make([]string, 0, len(relevant))
+	for key := range relevant {
+		queue = append(queue, key)
+	}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, assumer := range assumers[key] {
+			if !relevant[assumer] {
+				relevant[assumer] = true
+				queue = append(queue, assumer)
+			}
+		}
+	}
+	return relevant
+}
+
+// roleKey identifies a role by its partition, account and name, as the role ARNs of aws-auth omit the role path.
+func roleKey(roleARN string) string {
+	return client.AWSAuthRoleARN(roleARN)
+}
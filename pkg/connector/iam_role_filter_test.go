@@ -0,0 +1,74 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/conductorone/baton-eks/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIAMRoleFilter_Matches(t *testing.T) {
+	_, err := newIAMRoleFilter(nil, []string{"=value"}, false)
+	assert.Error(t, err)
+
+	filter, err := newIAMRoleFilter([]string{"/teams/", "/eks/"}, []string{"team=platform"}, false)
+	require.NoError(t, err)
+
+	assert.True(t, filter.matches(&client.IAMRole{Path: aws.String("/teams/platform/"), Tags: map[string]string{"team": "platform"}}))
+	assert.False(t, filter.matches(&client.IAMRole{Path: aws.String("/aws-service-role/"), Tags: map[string]string{"team": "platform"}}))
+	assert.False(t, filter.matches(&client.IAMRole{Path: aws.String("/eks/"), Tags: map[string]string{"team": "data"}}))
+	assert.False(t, filter.matches(&client.IAMRole{Tags: map[string]string{"team": "platform"}}))
+
+	var noFilter *iamRoleFilter
+	assert.True(t, noFilter.matches(&client.IAMRole{Path: aws.String("/aws-service-role/")}))
+}
+
+func TestExpandRelevantRoles(t *testing.T) {
+	const account = "arn:aws:iam::123456789012:"
+	trusts := map[string]*client.RoleTrust{
+		account + "role/irsa": {
+			Principals:    []client.TrustedPrincipal{{ARN: account + "role/irsa-assumer"}},
+			WebIdentities: []client.WebIdentityTrust{{Issuer: "oidc.eks.us-east-1.amazonaws.com/id/ABC"}},
+		},
+		account + "role/other-cluster": {
+			WebIdentities: []client.WebIdentityTrust{{Issuer: "oidc.eks.us-east-1.amazonaws.com/id/XYZ"}},
+		},
+		account + "role/teams/admin": {
+			Principals: []client.TrustedPrincipal{{ARN: account + "role/ci/deployer"}},
+		},
+		account + "role/ci/deployer": {
+			Principals: []client.TrustedPrincipal{
+				{ARN: account + "role/bastion"},
+				{ARN: account + "user/alice"},
+			},
+		},
+		account + "role/irsa-assumer": {},
+		account + "role/unrelated": {
+			Principals: []client.TrustedPrincipal{{ARN: account + "role/bastion"}},
+		},
+	}
+
+	relevant := expandRelevantRoles(
+		// aws-auth role ARNs omit the path of the role.
+		map[string]bool{account + "role/admin": true},
+		map[string]bool{"oidc.eks.us-east-1.amazonaws.com/id/ABC": true},
+		trusts,
+	)
+
+	assert.Equal(t, map[string]bool{
+		account + "role/admin":        true,
+		account + "role/deployer":     true,
+		account + "role/bastion":      true,
+		account + "role/irsa":         true,
+		account + "role/irsa-assumer": true,
+	}, relevant)
+}
+
+func TestRoleKey(t *testing.T) {
+	assert.Equal(t, "arn:aws:iam::123456789012:role/admin", roleKey("arn:aws:iam::123456789012:role/teams/platform/admin"))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/admin", roleKey("arn:aws:iam::123456789012:role/admin"))
+	assert.Equal(t, "arn:aws:iam::123456789012:user/alice", roleKey("arn:aws:iam::123456789012:user/alice"))
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:role/admin", roleKey("arn:aws-us-gov:iam::123456789012:role/teams/admin"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/conductorone/baton-eks/pkg/client"
@@ -23,6 +24,11 @@ import (
 type iamRoleBuilder struct {
 	eksClient    *client.EKSClient
 	resourceType *v2.ResourceType
+	irsa         *irsaIndex
+	podIdentity  *podIdentityIndex
+	filter       *iamRoleFilter
+	relevantMtx  sync.Mutex
+	relevant     map[string]bool
 }
 
 // ResourceType returns the resource type for IAM Roles.
@@ -51,7 +57,19 @@ func (i *iamRoleBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 		return nil, "", nil, fmt.Errorf("failed to list IAM roles: %w", err)
 	}
 
+	var relevant map[string]bool
+	if i.filter != nil && i.filter.relevantOnly {
+		relevant, err = i.relevantRoles(ctx, bag.PageToken() == "")
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to find relevant IAM roles: %w", err)
+		}
+	}
+
 	for _, role := range roles {
+		if !i.filter.matches(role) || (relevant != nil && !relevant[roleKey(role.ARN)]) {
+			continue
+		}
+
 		resource, err := i.roleResource(role)
 		if err != nil {
 			l.Error("failed to create role resource",
@@ -91,6 +109,9 @@ func (i *iamRoleBuilder) roleResource(role *client.IAMRole) (*v2.Resource, error
 	if role.CreateDate != nil {
 		profile["create_date"] = role.CreateDate.Format("2006-01-02T15:04:05Z")
 	}
+	if len(role.Tags) > 0 {
+		profile["tags"] = k8s.StringMapToAnyMap(role.Tags)
+	}
 
 	// Create resource as a role
 	resource, err := rs.NewRoleResource(
@@ -113,9 +134,8 @@ func (i *iamRoleBuilder) Entitlements(ctx context.Context, resource *v2.Resource
 		"assignment",
 		entitlement.WithDisplayName(fmt.Sprintf("%s Role", resource.DisplayName)),
 		entitlement.WithDescription(fmt.Sprintf("Can assume the %s role in AWS", resource.DisplayName)),
-		entitlement.WithGrantableTo(
-			ResourceTypeIAMUser,
-		),
+		// Only service accounts can be granted the role, through EKS Pod Identity; trusts of IAM users and roles are read-only.
+		entitlement.WithGrantableTo(k8s.ResourceTypeServiceAccount),
 	)
 
 	return []*v2.Entitlement{assigmentEnt}, "", nil, nil
@@ -142,7 +162,7 @@ func (i *iamRoleBuilder) Grants(ctx context.Context, resource *v2.Resource, pTok
 	roleName := parts[len(parts)-1]
 
 	// Get principals that can assume this role
-	principals, err := i.eksClient.GetIAMRoleTrustPrincipals(ctx, roleName)
+	trust, err := i.eksClient.GetIAMRoleTrust(ctx, roleName)
 	if err != nil {
 		l.Error("failed to get role trust principals",
 			zap.String("role_name", roleName),
@@ -152,35 +172,190 @@ func (i *iamRoleBuilder) Grants(ctx context.Context, resource *v2.Resource, pTok
 	}
 
 	// Create grants for principals that can assume this role
-	for _, principal := range principals {
-		// Skip wildcard principals and service principals for now
-		if principal == "*" || strings.Contains(principal, ":service/") {
+	for _, principal := range trust.Principals {
+		g := trustGrant(resource, roleARN, principal)
+		if g == nil {
+			// Services, other accounts and everyone ("*") aren't synced
+			l.Debug("skipping role trust principal",
+				zap.String("role_arn", roleARN),
+				zap.String("principal_arn", principal.ARN))
 			continue
 		}
+		rv = append(rv, g)
 
-		// Only handle IAM user principals
-		if strings.Contains(principal, ":user/") {
-			principalResource := k8s.GenerateResourceForGrant(principal, ResourceTypeIAMUser.Id)
-			g := grant.NewGrant(
-				resource,
-				"assignment",
-				principalResource,
-			)
-			rv = append(rv, g)
-
-			l.Debug("created role assume grant",
-				zap.String("role_arn", roleARN),
-				zap.String("principal_arn", principal))
-		}
+		l.Debug("created role assume grant",
+			zap.String("role_arn", roleARN),
+			zap.String("principal_arn", principal.ARN))
 	}
 
+	// Service accounts that can assume this role through the OIDC provider of their cluster (IRSA)
+	rv = append(rv, i.irsa.grants(ctx, resource, "assignment", roleARN, trust.WebIdentities)...)
+
+	// Service accounts associated with this role through EKS Pod Identity
+	rv = appendNewGrants(rv, i.podIdentity.grants(ctx, resource, "assignment", roleARN))
+
 	return rv, "", nil, nil
 }
 
+// trustGrant returns the assignment grant of the role to a principal its trust policy lets assume it,
+// or nil if the principal isn't an IAM user or another role.
+func trustGrant(resource *v2.Resource, roleARN string, principal client.TrustedPrincipal) *v2.Grant {
+	var grantOpts []grant.GrantOption
+	var principalResource *v2.Resource
+	switch {
+	case client.IsIAMUserARN(principal.ARN):
+		principalResource = k8s.GenerateResourceForGrant(principal.ARN, ResourceTypeIAMUser.Id)
+	case client.IsIAMRoleARN(principal.ARN) && principal.ARN != roleARN:
+		// Whoever can assume the trusted role can assume this role from it (role chaining).
+		principalResource = k8s.GenerateResourceForGrant(principal.ARN, ResourceTypeIAMRole.Id)
+		grantOpts = append(grantOpts, grant.WithAnnotation(&v2.GrantExpandable{
+			EntitlementIds: []string{
+				fmt.Sprintf("role:%s:assignment", principal.ARN),
+			},
+		}))
+	default:
+		return nil
+	}
+
+	metadata := trustGrantMetadata(principal.Conditions, principal.DenyConditions)
+	if principal.Via != "" {
+		metadata["trusted_via"] = principal.Via
+	}
+	if principal.SessionTags {
+		metadata["session_tags"] = true
+	}
+	if len(metadata) > 0 {
+		grantOpts = append(grantOpts, grant.WithGrantMetadata(metadata))
+	}
+
+	return grant.NewGrant(resource, "assignment", principalResource, grantOpts...)
+}
+
+// trustGrantMetadata returns the metadata of a grant that depends on trust policy conditions.
+func trustGrantMetadata(conditions []client.PolicyCondition, denyConditions []client.PolicyCondition) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if len(conditions) > 0 {
+		metadata["conditions"] = policyConditionStrings(conditions)
+	}
+	if len(denyConditions) > 0 {
+		metadata["deny_conditions"] = policyConditionStrings(denyConditions)
+	}
+	return metadata
+}
+
+func policyConditionStrings(conditions []client.PolicyCondition) []interface{} {
+	rv := make([]interface{}, 0, len(conditions))
+	for _, condition := range conditions {
+		rv = append(rv, condition.String())
+	}
+	return rv
+}
+
+// appendNewGrants appends the grants whose principal isn't granted yet, so service accounts
+// that can assume the role both through IRSA and Pod Identity are granted once.
+func appendNewGrants(grants []*v2.Grant, more []*v2.Grant) []*v2.Grant {
+	seen := make(map[string]bool, len(grants))
+	for _, g := range grants {
+		seen[g.Principal.Id.ResourceType+"/"+g.Principal.Id.Resource] = true
+	}
+	for _, g := range more {
+		key := g.Principal.Id.ResourceType + "/" + g.Principal.Id.Resource
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		grants = append(grants, g)
+	}
+	return grants
+}
+
+// Grant associates the role with a Kubernetes service account through EKS Pod Identity.
+func (i *iamRoleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != k8s.ResourceTypeServiceAccount.Id {
+		return nil, fmt.Errorf("only service accounts can be granted IAM roles, got %s", principal.Id.ResourceType)
+	}
+
+	cluster, saID, err := i.podIdentity.clusters.lookup(principal.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	namespace, name, err := parseServiceAccountID(saID)
+	if err != nil {
+		return nil, err
+	}
+	roleARN := entitlement.Resource.Id.Resource
+
+	existing, err := i.podIdentity.serviceAccount(ctx, cluster, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod identity associations: %w", err)
+	}
+	if existing != nil {
+		if existing.RoleARN == roleARN {
+			return annotations.New(&v2.GrantAlreadyExists{}), nil
+		}
+		// EKS allows a single association per service account.
+		return nil, fmt.Errorf("service account %s is already associated with role %s", saID, existing.RoleARN)
+	}
+
+	l.Debug("creating pod identity association",
+		zap.String("cluster", cluster.id),
+		zap.String("service_account", saID),
+		zap.String("role_arn", roleARN))
+
+	err = cluster.podIdentities.CreatePodIdentityAssociation(ctx, namespace, name, roleARN)
+	i.podIdentity.invalidate(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Revoke deletes the EKS Pod Identity association of the role with a Kubernetes service account.
+func (i *iamRoleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	principal := grant.Principal
+	if principal.Id.ResourceType != k8s.ResourceTypeServiceAccount.Id {
+		return nil, fmt.Errorf("only service accounts can be revoked IAM roles, got %s", principal.Id.ResourceType)
+	}
+
+	cluster, saID, err := i.podIdentity.clusters.lookup(principal.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	namespace, name, err := parseServiceAccountID(saID)
+	if err != nil {
+		return nil, err
+	}
+	roleARN := grant.Entitlement.Resource.Id.Resource
+
+	existing, err := i.podIdentity.serviceAccount(ctx, cluster, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod identity associations: %w", err)
+	}
+	// Service accounts that assume the role through IRSA have no association to delete.
+	if existing == nil || existing.RoleARN != roleARN {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	err = cluster.podIdentities.DeletePodIdentityAssociation(ctx, existing.AssociationID)
+	i.podIdentity.invalidate(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
 // NewIAMRoleBuilder creates a new IAM role builder.
-func NewIAMRoleBuilder(eksClient *client.EKSClient) *iamRoleBuilder {
+// A nil filter syncs every role.
+func NewIAMRoleBuilder(eksClient *client.EKSClient, clusters *clusterRegistry, filter *iamRoleFilter) *iamRoleBuilder {
 	return &iamRoleBuilder{
 		eksClient:    eksClient,
 		resourceType: ResourceTypeIAMRole,
+		irsa:         newIRSAIndex(clusters),
+		podIdentity:  newPodIdentityIndex(clusters),
+		filter:       filter,
 	}
 }
@@ -1,10 +1,16 @@
 package connector
 
 import (
+	"context"
 	"testing"
 
 	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIAMRoleBuilder_ResourceType(t *testing.T) {
@@ -12,7 +18,7 @@ func TestIAMRoleBuilder_ResourceType(t *testing.T) {
 	var eksClient *client.EKSClient
 
 	// Create IAM role builder
-	builder := NewIAMRoleBuilder(eksClient)
+	builder := NewIAMRoleBuilder(eksClient, newClusterRegistry(false), nil)
 
 	// Test resource type
 	resourceType := builder.ResourceType(t.Context())
@@ -28,7 +34,7 @@ func TestIAMRoleBuilder_RoleResource(t *testing.T) {
 	var eksClient *client.EKSClient
 
 	// Create IAM role builder
-	builder := NewIAMRoleBuilder(eksClient)
+	builder := NewIAMRoleBuilder(eksClient, newClusterRegistry(false), nil)
 
 	// Create a test IAM role
 	testRole := &client.IAMRole{
@@ -46,3 +52,74 @@ func TestIAMRoleBuilder_RoleResource(t *testing.T) {
 	assert.Equal(t, "arn:aws:iam::123456789012:role/test-role", resource.Id.Resource)
 	assert.Equal(t, "role", resource.Id.ResourceType)
 }
+
+func TestIAMRoleBuilder_Entitlements(t *testing.T) {
+	resource := &v2.Resource{
+		Id:          &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: "arn:aws:iam::123456789012:role/deployer"},
+		DisplayName: "deployer",
+	}
+
+	entitlements, _, _, err := (&iamRoleBuilder{}).Entitlements(context.Background(), resource, &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, entitlements, 1)
+
+	// Only service accounts can be granted the role, through EKS Pod Identity.
+	var grantableTo []string
+	for _, resourceType := range entitlements[0].GrantableTo {
+		grantableTo = append(grantableTo, resourceType.Id)
+	}
+	assert.Equal(t, []string{k8s.ResourceTypeServiceAccount.Id}, grantableTo)
+}
+
+func TestMatchWebIdentityTrust(t *testing.T) {
+	issuer := "oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+	trusts := []client.WebIdentityTrust{
+		{Issuer: issuer, Subjects: []string{serviceAccountSubject("default", "builder")}},
+		{Issuer: "oidc.eks.eu-west-1.amazonaws.com/id/OTHER", SubjectPatterns: []string{"*"}},
+	}
+
+	assert.Equal(t, &trusts[0], matchWebIdentityTrust(trusts, issuer, serviceAccountSubject("default", "builder")))
+	assert.Nil(t, matchWebIdentityTrust(trusts, issuer, serviceAccountSubject("default", "deployer")))
+	assert.Equal(t, &trusts[1], matchWebIdentityTrust(trusts, "oidc.eks.eu-west-1.amazonaws.com/id/OTHER", serviceAccountSubject("ci", "runner")))
+	assert.Nil(t, matchWebIdentityTrust(trusts, "", serviceAccountSubject("default", "builder")))
+}
+
+func TestTrustGrant(t *testing.T) {
+	roleARN := "arn:aws:iam::123456789012:role/test-role"
+	resource := &v2.Resource{Id: &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: roleARN}}
+
+	userGrant := trustGrant(resource, roleARN, client.TrustedPrincipal{ARN: "arn:aws:iam::123456789012:user/alice"})
+	require.NotNil(t, userGrant)
+	assert.Equal(t, ResourceTypeIAMUser.Id, userGrant.Principal.Id.ResourceType)
+	assert.Empty(t, userGrant.Annotations)
+
+	// Roles that can assume the role expand to whoever can assume them.
+	roleGrant := trustGrant(resource, roleARN, client.TrustedPrincipal{
+		ARN:         "arn:aws:iam::123456789012:role/deployer",
+		Via:         "arn:aws:iam::123456789012:root",
+		SessionTags: true,
+		Conditions:  []client.PolicyCondition{{Operator: "StringEquals", Key: "sts:ExternalId", Values: []string{"secret"}}},
+	})
+	require.NotNil(t, roleGrant)
+	assert.Equal(t, ResourceTypeIAMRole.Id, roleGrant.Principal.Id.ResourceType)
+
+	annos := annotations.Annotations(roleGrant.Annotations)
+	expandable := &v2.GrantExpandable{}
+	ok, err := annos.Pick(expandable)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"role:arn:aws:iam::123456789012:role/deployer:assignment"}, expandable.EntitlementIds)
+
+	metadata := &v2.GrantMetadata{}
+	ok, err = annos.Pick(metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	fields := metadata.Metadata.AsMap()
+	assert.Equal(t, []interface{}{"StringEquals sts:ExternalId secret"}, fields["conditions"])
+	assert.Equal(t, "arn:aws:iam::123456789012:root", fields["trusted_via"])
+	assert.Equal(t, true, fields["session_tags"])
+
+	assert.Nil(t, trustGrant(resource, roleARN, client.TrustedPrincipal{ARN: roleARN}))
+	assert.Nil(t, trustGrant(resource, roleARN, client.TrustedPrincipal{ARN: "arn:aws:iam::210987654321:root"}))
+	assert.Nil(t, trustGrant(resource, roleARN, client.TrustedPrincipal{ARN: "*"}))
+}
@@ -0,0 +1,232 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// iamUserBuilder syncs AWS IAM users as Baton resources, so that grants to IAM users
+// resolve without an external AWS connector.
+type iamUserBuilder struct {
+	iamClient client.IAMUserClient
+	clusters  *clusterRegistry
+	// referencedOnly limits the sync to users referenced by aws-auth, access entries or role trust policies.
+	referencedOnly bool
+	referencedMtx  sync.Mutex
+	referenced     *iamUserReferences
+}
+
+// iamUserReferences are the IAM users referenced by the clusters or by role trust policies.
+type iamUserReferences struct {
+	users map[string]bool
+	// accounts are mapped as a whole by aws-auth mapAccounts, referencing all of their users.
+	accounts map[string]bool
+}
+
+func (r *iamUserReferences) has(userARN string) bool {
+	if r.users[userARN] {
+		return true
+	}
+	principal, err := client.ParseIAMPrincipalARN(userARN)
+	return err == nil && r.accounts[principal.AccountID]
+}
+
+// ResourceType returns the resource type for IAM users.
+func (i *iamUserBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return ResourceTypeIAMUser
+}
+
+// List fetches IAM users from the AWS API.
+func (i *iamUserBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+	var rv []*v2.Resource
+
+	bag, err := k8s.ParsePageToken(pToken.Token)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to parse page token: %w", err)
+	}
+
+	var referenced *iamUserReferences
+	if i.referencedOnly {
+		referenced, err = i.referencedUsers(ctx, bag.PageToken() == "")
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	var iamNextToken *string
+	if bag.PageToken() != "" {
+		iamNextToken = aws.String(bag.PageToken())
+	}
+
+	users, nextPageToken, err := i.iamClient.ListIAMUsers(ctx, iamNextToken)
+	if err != nil {
+		l.Error("failed to list IAM users", zap.Error(err))
+		return nil, "", nil, fmt.Errorf("failed to list IAM users: %w", err)
+	}
+
+	for _, user := range users {
+		if referenced != nil && !referenced.has(user.ARN) {
+			continue
+		}
+
+		if err := i.iamClient.GetIAMUserDetails(ctx, user); err != nil {
+			// The user is still synced, without tags and MFA status.
+			l.Warn("failed to get IAM user details",
+				zap.String("user_name", user.UserName),
+				zap.Error(err))
+		}
+
+		resource, err := iamUserResource(user)
+		if err != nil {
+			l.Error("failed to create user resource",
+				zap.String("user_name", user.UserName),
+				zap.Error(err))
+			continue
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPageTokenStr string
+	if nextPageToken != nil && *nextPageToken != "" {
+		bag.Push(pagination.PageState{Token: *nextPageToken})
+		token, err := bag.Marshal()
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to marshal pagination bag: %w", err)
+		}
+		nextPageTokenStr = token
+	}
+
+	return rv, nextPageTokenStr, nil, nil
+}
+
+// referencedUsers returns the IAM users referenced by the aws-auth ConfigMaps and access entries
+// of the clusters, or by role trust policies. They are collected again at the start of each sync.
+func (i *iamUserBuilder) referencedUsers(ctx context.Context, refresh bool) (*iamUserReferences, error) {
+	i.referencedMtx.Lock()
+	defer i.referencedMtx.Unlock()
+
+	if i.referenced != nil && !refresh {
+		return i.referenced, nil
+	}
+
+	l := ctxzap.Extract(ctx)
+	referenced := &iamUserReferences{
+		users:    make(map[string]bool),
+		accounts: make(map[string]bool),
+	}
+
+	for _, cluster := range i.clusters.all() {
+		principals, err := cluster.identity.ListMappedPrincipals(ctx)
+		if err != nil {
+			l.Warn("failed to list IAM principals mapped in cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+			continue
+		}
+		for _, principal := range principals {
+			referenced.users[principal] = true
+		}
+
+		accounts, err := cluster.identity.ListMappedAccounts(ctx)
+		if err != nil {
+			l.Warn("failed to list AWS accounts mapped in cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+			continue
+		}
+		for _, account := range accounts {
+			referenced.accounts[account] = true
+		}
+	}
+
+	principals, err := i.iamClient.ListRoleTrustPrincipals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role trust principals: %w", err)
+	}
+	for _, principal := range principals {
+		referenced.users[principal] = true
+	}
+
+	i.referenced = referenced
+	return referenced, nil
+}
+
+// iamUserResource creates a Baton resource from an AWS IAM user.
+func iamUserResource(user *client.IAMUser) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"user_name":   user.UserName,
+		"user_id":     user.UserID,
+		"arn":         user.ARN,
+		"mfa_enabled": user.MFAEnabled,
+	}
+	if user.Path != nil {
+		profile["path"] = *user.Path
+	}
+	if user.CreateDate != nil {
+		profile["create_date"] = user.CreateDate.Format("2006-01-02T15:04:05Z")
+	}
+	if user.PasswordLastUsed != nil {
+		profile["password_last_used"] = user.PasswordLastUsed.Format("2006-01-02T15:04:05Z")
+	}
+	if len(user.Tags) > 0 {
+		profile["tags"] = k8s.StringMapToAnyMap(user.Tags)
+	}
+
+	opts := []rs.UserTraitOption{
+		rs.WithUserProfile(profile),
+		rs.WithStatus(v2.UserTrait_Status_STATUS_ENABLED),
+		rs.WithUserLogin(user.UserName),
+		rs.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_HUMAN),
+		rs.WithMFAStatus(&v2.UserTrait_MFAStatus{MfaEnabled: user.MFAEnabled}),
+	}
+	if user.CreateDate != nil {
+		opts = append(opts, rs.WithCreatedAt(*user.CreateDate))
+	}
+	if user.PasswordLastUsed != nil {
+		opts = append(opts, rs.WithLastLogin(*user.PasswordLastUsed))
+	}
+
+	// The ARN is the resource ID, matching the principal ID of IAM user grants.
+	resource, err := rs.NewUserResource(
+		user.UserName,
+		ResourceTypeIAMUser,
+		user.ARN,
+		opts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user resource: %w", err)
+	}
+
+	return resource, nil
+}
+
+// Entitlements returns no entitlements for IAM users.
+func (i *iamUserBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// Grants returns no grants for IAM users.
+func (i *iamUserBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// NewIAMUserBuilder creates a new IAM user builder.
+func NewIAMUserBuilder(iamClient client.IAMUserClient, clusters *clusterRegistry, referencedOnly bool) *iamUserBuilder {
+	return &iamUserBuilder{
+		iamClient:      iamClient,
+		clusters:       clusters,
+		referencedOnly: referencedOnly,
+	}
+}
@@ -0,0 +1,112 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-eks/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIAMUserClient implements the IAMUserClient interface for testing.
+type mockIAMUserClient struct {
+	users      []*client.IAMUser
+	trustedBy  map[string][]string
+	roleTrusts int
+}
+
+func (m *mockIAMUserClient) ListIAMUsers(ctx context.Context, nextToken *string) ([]*client.IAMUser, *string, error) {
+	var users []*client.IAMUser
+	for _, user := range m.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+	return users, nil, nil
+}
+
+func (m *mockIAMUserClient) GetIAMUserDetails(ctx context.Context, user *client.IAMUser) error {
+	user.Tags = map[string]string{"team": "platform"}
+	user.MFAEnabled = user.UserName == "alice"
+	return nil
+}
+
+func (m *mockIAMUserClient) ListRoleTrustPrincipals(ctx context.Context) ([]string, error) {
+	m.roleTrusts++
+	var principals []string
+	for _, trusted := range m.trustedBy {
+		principals = append(principals, trusted...)
+	}
+	return principals, nil
+}
+
+func newTestIAMUserClient() *mockIAMUserClient {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &mockIAMUserClient{
+		users: []*client.IAMUser{
+			{UserName: "alice", UserID: "AIDAALICE", ARN: "arn:aws:iam::123456789012:user/alice", CreateDate: &created},
+			{UserName: "bob", UserID: "AIDABOB", ARN: "arn:aws:iam::123456789012:user/bob"},
+			{UserName: "carol", UserID: "AIDACAROL", ARN: "arn:aws:iam::123456789012:user/carol"},
+		},
+		trustedBy: map[string][]string{
+			"deployer": {"arn:aws:iam::123456789012:user/carol"},
+		},
+	}
+}
+
+func TestIAMUserBuilder_List(t *testing.T) {
+	builder := NewIAMUserBuilder(newTestIAMUserClient(), newClusterRegistry(false), false)
+
+	resources, nextToken, _, err := builder.List(context.Background(), nil, &pagination.Token{})
+	require.NoError(t, err)
+	assert.Empty(t, nextToken)
+	require.Len(t, resources, 3)
+
+	alice := resources[0]
+	assert.Equal(t, "arn:aws:iam::123456789012:user/alice", alice.Id.Resource)
+	assert.Equal(t, ResourceTypeIAMUser.Id, alice.Id.ResourceType)
+
+	userTrait := &v2.UserTrait{}
+	annos := annotations.Annotations(alice.Annotations)
+	ok, err := annos.Pick(userTrait)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, userTrait.GetMfaStatus().GetMfaEnabled())
+	assert.Equal(t, "2024-01-02T03:04:05Z", userTrait.GetProfile().GetFields()["create_date"].GetStringValue())
+	assert.Equal(t, "platform", userTrait.GetProfile().GetFields()["tags"].GetStructValue().GetFields()["team"].GetStringValue())
+}
+
+func TestIAMUserBuilder_ListReferencedOnly(t *testing.T) {
+	registry := newTestClusterRegistry(false,
+		withIdentity(&MockEKSClient{
+			usersByUsername: map[string][]string{"alice": {"arn:aws:iam::123456789012:user/alice"}},
+		}),
+	)
+	iamClient := newTestIAMUserClient()
+	builder := NewIAMUserBuilder(iamClient, registry, true)
+
+	resources, _, _, err := builder.List(context.Background(), nil, &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "arn:aws:iam::123456789012:user/alice", resources[0].Id.Resource)
+	assert.Equal(t, "arn:aws:iam::123456789012:user/carol", resources[1].Id.Resource)
+	assert.Equal(t, 1, iamClient.roleTrusts)
+}
+
+func TestIAMUserBuilder_ListReferencedOnlyMappedAccounts(t *testing.T) {
+	registry := newTestClusterRegistry(false,
+		withIdentity(&MockEKSClient{
+			mappedAccounts: []string{"123456789012"},
+		}),
+	)
+	builder := NewIAMUserBuilder(newTestIAMUserClient(), registry, true)
+
+	// Every user of an account mapped by mapAccounts is referenced.
+	resources, _, _, err := builder.List(context.Background(), nil, &pagination.Token{})
+	require.NoError(t, err)
+	assert.Len(t, resources, 3)
+}
@@ -0,0 +1,361 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/conductorone/baton-eks/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// identityMapping is the mechanism that maps an IAM principal to a Kubernetes username on a cluster.
+type identityMapping int
+
+const (
+	identityMappingAWSAuth identityMapping = iota
+	identityMappingAccessEntry
+)
+
+func (m identityMapping) String() string {
+	if m == identityMappingAccessEntry {
+		return "access_entry"
+	}
+	return "aws_auth"
+}
+
+// identityMappingsFor returns the identity mappings a cluster honors for its authentication mode, preferred first.
+// Clusters that don't report an authentication mode predate access entries and only honor the aws-auth ConfigMap.
+func identityMappingsFor(mode eksTypes.AuthenticationMode, preferAccessEntries bool) []identityMapping {
+	switch mode {
+	case eksTypes.AuthenticationModeApi:
+		return []identityMapping{identityMappingAccessEntry}
+	case eksTypes.AuthenticationModeApiAndConfigMap:
+		if preferAccessEntries {
+			return []identityMapping{identityMappingAccessEntry, identityMappingAWSAuth}
+		}
+		return []identityMapping{identityMappingAWSAuth, identityMappingAccessEntry}
+	default:
+		return []identityMapping{identityMappingAWSAuth}
+	}
+}
+
+// identityMapper maps IAM principals to Kubernetes usernames for RBAC provisioning,
+// using access entries or the aws-auth ConfigMap depending on the cluster's authentication mode.
+type identityMapper struct {
+	preferAccessEntries bool
+	// roles resolves the role ARNs of aws-auth, which omit the role path, to the ARNs of the IAM roles.
+	roles client.RoleARNResolver
+}
+
+// mappings returns the identity mappings of the cluster, preferred first.
+func (m *identityMapper) mappings(ctx context.Context, cluster *eksCluster) ([]identityMapping, error) {
+	description, err := cluster.info.DescribeCluster(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe cluster %s: %w", cluster.id, err)
+	}
+
+	var mode eksTypes.AuthenticationMode
+	if description.AccessConfig != nil {
+		mode = description.AccessConfig.AuthenticationMode
+	}
+	return identityMappingsFor(mode, m.preferAccessEntries), nil
+}
+
+// getOrCreateUsername returns the Kubernetes username of the principal, mapping it with the preferred
+// mechanism of the cluster if it isn't mapped yet.
+func (m *identityMapper) getOrCreateUsername(ctx context.Context, cluster *eksCluster, principalARN string) (string, error) {
+	l := ctxzap.Extract(ctx)
+
+	mappings, err := m.mappings(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+
+	username, found, err := m.lookupUsernameIn(ctx, cluster, principalARN, mappings)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		return username, nil
+	}
+
+	l.Debug("mapping principal to kubernetes username",
+		zap.String("cluster", cluster.id),
+		zap.String("principal", principalARN),
+		zap.Stringer("mapping", mappings[0]))
+
+	switch mappings[0] {
+	case identityMappingAccessEntry:
+		_, err = cluster.eksClient.CreateAccessEntryWithMapping(ctx, principalARN, principalARN, nil)
+		if err != nil {
+			return "", err
+		}
+	default:
+		if client.IsIAMRoleARN(principalARN) {
+			err = cluster.eksClient.AddIAMRoleMapping(ctx, principalARN)
+			if err != nil {
+				return "", fmt.Errorf("failed to add IAM role mapping: %w", err)
+			}
+			return client.AWSAuthRoleARN(principalARN), nil
+		}
+		err = cluster.eksClient.AddIAMUserMapping(ctx, principalARN)
+		if err != nil {
+			return "", fmt.Errorf("failed to add IAM user mapping: %w", err)
+		}
+	}
+	return principalARN, nil
+}
+
+// lookupUsername returns the Kubernetes username the principal is mapped to, if any.
+func (m *identityMapper) lookupUsername(ctx context.Context, cluster *eksCluster, principalARN string) (string, bool, error) {
+	mappings, err := m.mappings(ctx, cluster)
+	if err != nil {
+		return "", false, err
+	}
+	return m.lookupUsernameIn(ctx, cluster, principalARN, mappings)
+}
+
+// lookupUsernameIn looks the principal up in each identity mapping, in order.
+func (m *identityMapper) lookupUsernameIn(ctx context.Context, cluster *eksCluster, principalARN string, mappings []identityMapping) (string, bool, error) {
+	for _, mapping := range mappings {
+		switch mapping {
+		case identityMappingAccessEntry:
+			accessEntry, err := cluster.accessEntries.DescribeAccessEntry(ctx, principalARN)
+			if err != nil {
+				if isAccessEntryNotFoundError(err) {
+					continue
+				}
+				return "", false, err
+			}
+			// Templated usernames, such as those of roles, vary by session and can't be bound.
+			if client.IsUsernameTemplate(accessEntry.Username) {
+				return "", false, fmt.Errorf("access entry of %s maps to templated username %s, which can't be bound", principalARN, accessEntry.Username)
+			}
+			if accessEntry.Username == "" {
+				return principalARN, true, nil
+			}
+			return accessEntry.Username, true, nil
+		default:
+			awsAuthMapping, err := cluster.eksClient.GetAWSAuthMapping(ctx, principalARN)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to get mapping from aws-auth ConfigMap: %w", err)
+			}
+			if awsAuthMapping == nil {
+				continue
+			}
+			if client.IsUsernameTemplate(awsAuthMapping.Username) {
+				return "", false, fmt.Errorf("aws-auth mapping of %s maps to templated username %s, which can't be bound", principalARN, awsAuthMapping.Username)
+			}
+			return awsAuthMapping.Username, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// addGroup adds the Kubernetes group to the principal's existing identity mapping,
+// mapping the principal with the preferred mechanism of the cluster if it isn't mapped yet.
+// It returns false if the principal was already a member of the group.
+func (m *identityMapper) addGroup(ctx context.Context, cluster *eksCluster, principalARN string, group string) (bool, error) {
+	mappings, err := m.mappings(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	added := false
+	addGroup := func(groups []string) []string {
+		if slices.Contains(groups, group) {
+			return groups
+		}
+		added = true
+		return append(groups, group)
+	}
+
+	for _, mapping := range mappings {
+		found, err := m.updateGroups(ctx, cluster, mapping, principalARN, addGroup)
+		if err != nil {
+			return false, err
+		}
+		if found {
+			return added, nil
+		}
+	}
+
+	switch mappings[0] {
+	case identityMappingAccessEntry:
+		// The default username of roles is templated, so the principal is mapped to its ARN to stay bindable.
+		_, err = cluster.eksClient.CreateAccessEntryWithMapping(ctx, principalARN, principalARN, []string{group})
+		if err != nil {
+			return false, err
+		}
+	default:
+		if client.IsIAMRoleARN(principalARN) {
+			err = cluster.eksClient.AddIAMRoleMapping(ctx, principalARN)
+			if err != nil {
+				return false, fmt.Errorf("failed to add IAM role mapping: %w", err)
+			}
+		} else {
+			err = cluster.eksClient.AddIAMUserMapping(ctx, principalARN)
+			if err != nil {
+				return false, fmt.Errorf("failed to add IAM user mapping: %w", err)
+			}
+		}
+		_, err = m.updateGroups(ctx, cluster, identityMappingAWSAuth, principalARN, addGroup)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// removeGroup removes the Kubernetes group from the principal's identity mappings.
+// It returns false if the principal wasn't a member of the group.
+func (m *identityMapper) removeGroup(ctx context.Context, cluster *eksCluster, principalARN string, group string) (bool, error) {
+	mappings, err := m.mappings(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+	removeGroup := func(groups []string) []string {
+		remaining := slices.DeleteFunc(slices.Clone(groups), func(g string) bool { return g == group })
+		if len(remaining) != len(groups) {
+			removed = true
+		}
+		return remaining
+	}
+
+	// The principal may be mapped by both mechanisms on clusters that honor both.
+	for _, mapping := range mappings {
+		if _, err := m.updateGroups(ctx, cluster, mapping, principalARN, removeGroup); err != nil {
+			return false, err
+		}
+	}
+	return removed, nil
+}
+
+// updateGroups applies update to the Kubernetes groups of the principal's identity mapping.
+// Mappings are only written when update changes their groups. It returns false if the principal isn't mapped.
+func (m *identityMapper) updateGroups(
+	ctx context.Context,
+	cluster *eksCluster,
+	mapping identityMapping,
+	principalARN string,
+	update func(groups []string) []string,
+) (bool, error) {
+	switch mapping {
+	case identityMappingAccessEntry:
+		accessEntry, err := cluster.accessEntries.DescribeAccessEntry(ctx, principalARN)
+		if err != nil {
+			if isAccessEntryNotFoundError(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		groups := update(accessEntry.KubernetesGroups)
+		if slices.Equal(groups, accessEntry.KubernetesGroups) {
+			return true, nil
+		}
+		if accessEntry.Type != "" && accessEntry.Type != "STANDARD" {
+			return false, fmt.Errorf("can't change the kubernetes groups of %s access entry of %s", accessEntry.Type, principalARN)
+		}
+		return true, cluster.eksClient.UpdateAccessEntryGroups(ctx, principalARN, groups)
+	default:
+		return cluster.eksClient.UpdateAWSAuthMappingGroups(ctx, principalARN, update)
+	}
+}
+
+// releaseUsername removes the aws-auth mapping the connector created for the principal once no RoleBinding
+// or ClusterRoleBinding has its username as a subject, so that it can no longer authenticate to the cluster.
+// Failures are logged rather than returned, as the binding itself was already revoked.
+func (m *identityMapper) releaseUsername(ctx context.Context, cluster *eksCluster, principalARN string, username string) {
+	l := ctxzap.Extract(ctx).With(
+		zap.String("cluster", cluster.id),
+		zap.String("principal", principalARN),
+		zap.String("username", username),
+	)
+
+	mappings, err := m.mappings(ctx, cluster)
+	if err != nil {
+		l.Warn("failed to get identity mappings of cluster", zap.Error(err))
+		return
+	}
+	if !slices.Contains(mappings, identityMappingAWSAuth) {
+		return
+	}
+
+	bound, err := cluster.eksClient.IsUsernameBound(ctx, username)
+	if err != nil {
+		l.Warn("failed to check the remaining bindings of the kubernetes username", zap.Error(err))
+		return
+	}
+	if bound {
+		return
+	}
+
+	removed, err := cluster.eksClient.RemoveManagedAWSAuthMapping(ctx, principalARN)
+	if err != nil {
+		l.Warn("failed to remove aws-auth mapping of the principal", zap.Error(err))
+		return
+	}
+	if removed {
+		l.Info("removed aws-auth mapping of the principal, as it has no bindings left")
+	}
+}
+
+// groupMembers returns the IAM principals mapped to the Kubernetes group on the cluster.
+func (m *identityMapper) groupMembers(ctx context.Context, cluster *eksCluster, group string) ([]string, error) {
+	principalARNs, err := cluster.identity.LookupArnsByGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make([]string, 0, len(principalARNs))
+	for _, principalARN := range principalARNs {
+		resolved = append(resolved, m.resolveRoleARN(ctx, principalARN))
+	}
+	return resolved, nil
+}
+
+// usernamePrincipals returns the IAM principals mapped to the Kubernetes username on the cluster.
+func (m *identityMapper) usernamePrincipals(ctx context.Context, cluster *eksCluster, username string) ([]client.UsernameMatch, error) {
+	matches, err := cluster.identity.LookupArnsByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i].ARN = m.resolveRoleARN(ctx, matches[i].ARN)
+	}
+	return matches, nil
+}
+
+// resolveRoleARN returns the ARN of the IAM role that aws-auth maps without its path, so that grants
+// reference the role resource. The ARN is kept as it is if it can't be resolved.
+func (m *identityMapper) resolveRoleARN(ctx context.Context, principalARN string) string {
+	if m.roles == nil {
+		return principalARN
+	}
+	resolved, err := m.roles.ResolveRoleARN(ctx, principalARN)
+	if err != nil {
+		ctxzap.Extract(ctx).Warn("failed to resolve IAM role ARN",
+			zap.String("principal", principalARN),
+			zap.Error(err))
+		return principalARN
+	}
+	return resolved
+}
+
+func isAccessEntryNotFoundError(err error) bool {
+	var resourceNotFoundErr *eksTypes.ResourceNotFoundException
+	return errors.As(err, &resourceNotFoundErr)
+}
+
+// newIdentityMapper creates a new identity mapper.
+func newIdentityMapper(preferAccessEntries bool, roles client.RoleARNResolver) *identityMapper {
+	return &identityMapper{
+		preferAccessEntries: preferAccessEntries,
+		roles:               roles,
+	}
+}
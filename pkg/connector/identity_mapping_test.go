@@ -0,0 +1,98 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/conductorone/baton-eks/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockClusterClient implements the ClusterClient interface for testing.
+type mockClusterClient struct {
+	authenticationMode eksTypes.AuthenticationMode
+	arn                string
+}
+
+func (m *mockClusterClient) DescribeCluster(ctx context.Context) (*eksTypes.Cluster, error) {
+	return &eksTypes.Cluster{
+		Arn:          &m.arn,
+		AccessConfig: &eksTypes.AccessConfigResponse{AuthenticationMode: m.authenticationMode},
+	}, nil
+}
+
+// notFoundAccessEntryClient returns ResourceNotFoundException for principals without an access entry.
+type notFoundAccessEntryClient struct {
+	mockAccessEntryClient
+}
+
+func (m *notFoundAccessEntryClient) DescribeAccessEntry(ctx context.Context, principalARN string) (*client.AccessEntry, error) {
+	entry, ok := m.entries[principalARN]
+	if !ok {
+		return nil, &eksTypes.ResourceNotFoundException{}
+	}
+	return entry, nil
+}
+
+func TestIdentityMappingsFor(t *testing.T) {
+	tests := []struct {
+		name                string
+		mode                eksTypes.AuthenticationMode
+		preferAccessEntries bool
+		want                []identityMapping
+	}{
+		{name: "api", mode: eksTypes.AuthenticationModeApi, want: []identityMapping{identityMappingAccessEntry}},
+		{name: "config map", mode: eksTypes.AuthenticationModeConfigMap, want: []identityMapping{identityMappingAWSAuth}},
+		{name: "unreported mode", mode: "", want: []identityMapping{identityMappingAWSAuth}},
+		{
+			name: "api and config map",
+			mode: eksTypes.AuthenticationModeApiAndConfigMap,
+			want: []identityMapping{identityMappingAWSAuth, identityMappingAccessEntry},
+		},
+		{
+			name:                "api and config map preferring access entries",
+			mode:                eksTypes.AuthenticationModeApiAndConfigMap,
+			preferAccessEntries: true,
+			want:                []identityMapping{identityMappingAccessEntry, identityMappingAWSAuth},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, identityMappingsFor(tt.mode, tt.preferAccessEntries))
+		})
+	}
+}
+
+func TestIdentityMapper_LookupUsernameAPIMode(t *testing.T) {
+	cluster := newTestCluster(
+		withInfo(&mockClusterClient{authenticationMode: eksTypes.AuthenticationModeApi}),
+		withAccessEntries(&notFoundAccessEntryClient{mockAccessEntryClient{
+			entries: map[string]*client.AccessEntry{
+				"arn:aws:iam::123456789012:user/alice": {
+					PrincipalArn: "arn:aws:iam::123456789012:user/alice",
+					Username:     "alice",
+				},
+				"arn:aws:iam::123456789012:role/admin": {
+					PrincipalArn: "arn:aws:iam::123456789012:role/admin",
+					Username:     "arn:aws:sts::123456789012:assumed-role/admin/{{SessionName}}",
+				},
+			},
+		}}),
+	)
+	mapper := newIdentityMapper(false, nil)
+
+	username, found, err := mapper.lookupUsername(context.Background(), cluster, "arn:aws:iam::123456789012:user/alice")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "alice", username)
+
+	_, found, err = mapper.lookupUsername(context.Background(), cluster, "arn:aws:iam::123456789012:user/bob")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, _, err = mapper.lookupUsername(context.Background(), cluster, "arn:aws:iam::123456789012:role/admin")
+	assert.Error(t, err)
+}
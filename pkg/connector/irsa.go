@@ -0,0 +1,154 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// irsaRoleARNAnnotation is the service account annotation that selects the IAM role of its pods (IRSA).
+const irsaRoleARNAnnotation = "eks.amazonaws.com/role-arn"
+
+// clusterServiceAccounts are the service accounts of a cluster, with the OIDC issuer their tokens are signed by.
+type clusterServiceAccounts struct {
+	issuer          string
+	serviceAccounts []corev1.ServiceAccount
+	expiry          time.Time
+}
+
+// irsaIndex resolves the service accounts that can assume IAM roles through the OIDC providers of the clusters.
+type irsaIndex struct {
+	clusters  *clusterRegistry
+	mtx       sync.Mutex
+	byCluster map[string]*clusterServiceAccounts
+}
+
+// serviceAccounts returns the cached service accounts and OIDC issuer of the cluster.
+func (x *irsaIndex) serviceAccounts(ctx context.Context, cluster *eksCluster) (*clusterServiceAccounts, error) {
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	now := time.Now()
+	if cached, ok := x.byCluster[cluster.id]; ok && now.Before(cached.expiry) {
+		return cached, nil
+	}
+
+	description, err := cluster.info.DescribeCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry := &clusterServiceAccounts{expiry: now.Add(cacheTTL)}
+	if description.Identity != nil && description.Identity.Oidc != nil {
+		entry.issuer = strings.TrimPrefix(aws.ToString(description.Identity.Oidc.Issuer), "https://")
+	}
+
+	// Clusters without an OIDC issuer can't use IRSA.
+	if entry.issuer != "" {
+		continueAt := ""
+		for {
+			saList, err := cluster.kube.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{Continue: continueAt})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list service accounts: %w", err)
+			}
+			entry.serviceAccounts = append(entry.serviceAccounts, saList.Items...)
+			if saList.Continue == "" {
+				break
+			}
+			continueAt = saList.Continue
+		}
+	}
+
+	x.byCluster[cluster.id] = entry
+	return entry, nil
+}
+
+// grants returns the grants of the role's entitlement to the service accounts its web identity trusts allow.
+func (x *irsaIndex) grants(ctx context.Context, resource *v2.Resource, entitlementName string, roleARN string, trusts []client.WebIdentityTrust) []*v2.Grant {
+	l := ctxzap.Extract(ctx)
+	if len(trusts) == 0 {
+		return nil
+	}
+
+	var rv []*v2.Grant
+	for _, cluster := range x.clusters.all() {
+		cached, err := x.serviceAccounts(ctx, cluster)
+		if err != nil {
+			// Other clusters can still be resolved.
+			l.Warn("failed to get service accounts of cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+			continue
+		}
+
+		for _, sa := range cached.serviceAccounts {
+			subject := serviceAccountSubject(sa.Namespace, sa.Name)
+			trust := matchWebIdentityTrust(trusts, cached.issuer, subject)
+			if trust == nil {
+				if sa.Annotations[irsaRoleARNAnnotation] == roleARN {
+					l.Debug("service account is annotated with a role that doesn't trust it",
+						zap.String("cluster", cluster.id),
+						zap.String("service_account", subject),
+						zap.String("role_arn", roleARN))
+				}
+				continue
+			}
+
+			saID := x.clusters.scopeID(cluster, fmt.Sprintf("%s/%s", sa.Namespace, sa.Name))
+			var grantOpts []grant.GrantOption
+			if metadata := trustGrantMetadata(trust.Conditions, trust.DenyConditions); len(metadata) > 0 {
+				grantOpts = append(grantOpts, grant.WithGrantMetadata(metadata))
+			}
+			rv = append(rv, grant.NewGrant(
+				resource,
+				entitlementName,
+				k8s.GenerateResourceForGrant(saID, k8s.ResourceTypeServiceAccount.Id),
+				grantOpts...,
+			))
+		}
+	}
+	return rv
+}
+
+// serviceAccountSubject returns the subject of the tokens issued to a service account.
+func serviceAccountSubject(namespace string, name string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+}
+
+// matchWebIdentityTrust returns the first of the trusts that allows the subject of the issuer, or nil if none does.
+func matchWebIdentityTrust(trusts []client.WebIdentityTrust, issuer string, subject string) *client.WebIdentityTrust {
+	for i, trust := range trusts {
+		if trust.Issuer != issuer {
+			continue
+		}
+		for _, s := range trust.Subjects {
+			if s == subject {
+				return &trusts[i]
+			}
+		}
+		for _, pattern := range trust.SubjectPatterns {
+			if client.MatchIAMWildcard(pattern, subject) {
+				return &trusts[i]
+			}
+		}
+	}
+	return nil
+}
+
+func newIRSAIndex(clusters *clusterRegistry) *irsaIndex {
+	return &irsaIndex{
+		clusters:  clusters,
+		byCluster: make(map[string]*clusterServiceAccounts),
+	}
+}
@@ -0,0 +1,213 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const kubeGroupEntitlementMember = "member"
+
+// kubeGroupBuilder syncs the Kubernetes groups of EKS clusters, with their IAM members
+// from the aws-auth ConfigMap and access entries.
+type kubeGroupBuilder struct {
+	clusters   *clusterRegistry
+	identities *identityMapper
+	// groups lists the groups referenced by RBAC bindings.
+	groups *clusterResourceSyncer
+}
+
+// ResourceType returns the resource type for Kubernetes groups.
+func (k *kubeGroupBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return k8s.ResourceTypeKubeGroup
+}
+
+// List fetches the groups referenced by RBAC bindings, and on the first page, the groups
+// IAM principals are mapped to that no binding references.
+func (k *kubeGroupBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	cluster, _, err := k.clusters.lookupParent(parentResourceID)
+	if err != nil || cluster == nil {
+		return nil, "", nil, err
+	}
+
+	rv, nextPageToken, annos, err := k.groups.List(ctx, parentResourceID, pToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if pToken == nil || pToken.Token == "" {
+		rv, err = k.appendMappedGroups(ctx, cluster, rv)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	return rv, nextPageToken, annos, nil
+}
+
+// appendMappedGroups appends the groups IAM principals are mapped to that aren't in resources yet.
+func (k *kubeGroupBuilder) appendMappedGroups(ctx context.Context, cluster *eksCluster, resources []*v2.Resource) ([]*v2.Resource, error) {
+	l := ctxzap.Extract(ctx)
+
+	groups, err := cluster.identity.ListKubernetesGroups(ctx)
+	if err != nil {
+		// Groups referenced by bindings are still synced.
+		l.Warn("failed to list kubernetes groups of IAM principals",
+			zap.String("cluster", cluster.id),
+			zap.Error(err))
+		return resources, nil
+	}
+
+	seen := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		seen[resource.Id.Resource] = true
+	}
+
+	for _, group := range groups {
+		if seen[k.clusters.scopeID(cluster, group)] {
+			continue
+		}
+		resource, err := kubeGroupResource(group)
+		if err != nil {
+			return nil, err
+		}
+		resource = k.clusters.scopeResource(cluster, resource)
+		resource.ParentResourceId = clusterResourceID(cluster)
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// kubeGroupResource creates a Baton group resource for a Kubernetes group.
+func kubeGroupResource(groupName string) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"name": groupName,
+	}
+
+	resource, err := rs.NewGroupResource(
+		groupName,
+		k8s.ResourceTypeKubeGroup,
+		groupName,
+		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group resource: %w", err)
+	}
+
+	return resource, nil
+}
+
+// isSystemGroup reports whether the group is reserved by Kubernetes, such as system:masters.
+func isSystemGroup(groupName string) bool {
+	return strings.HasPrefix(groupName, "system:")
+}
+
+// Entitlements returns the member entitlement of the group.
+// Membership of system groups can't be provisioned.
+func (k *kubeGroupBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	_, groupName, err := k.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	opts := []entitlement.EntitlementOption{
+		entitlement.WithDisplayName(fmt.Sprintf("%s Group Member", resource.DisplayName)),
+		entitlement.WithDescription(fmt.Sprintf("Member of the %s Kubernetes group", resource.DisplayName)),
+		entitlement.WithGrantableTo(
+			ResourceTypeIAMUser,
+			ResourceTypeIAMRole,
+		),
+	}
+	if isSystemGroup(groupName) {
+		opts = append(opts, entitlement.WithAnnotation(&v2.EntitlementImmutable{}))
+	}
+
+	memberEnt := entitlement.NewAssignmentEntitlement(resource, kubeGroupEntitlementMember, opts...)
+
+	return []*v2.Entitlement{memberEnt}, "", nil, nil
+}
+
+// Grants returns the IAM users and roles mapped to the group by the aws-auth ConfigMap or access entries.
+func (k *kubeGroupBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	cluster, groupName, err := k.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	matchingARNs, err := k.identities.groupMembers(ctx, cluster, groupName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to lookup ARNs for group %s: %w", groupName, err)
+	}
+
+	return processGrants(matchingARNs, resource, kubeGroupEntitlementMember), "", nil, nil
+}
+
+// Grant adds the group to the identity mapping of the IAM user or role.
+func (k *kubeGroupBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	principalARN, err := iamPrincipalARN(principal.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, groupName, err := k.clusters.lookup(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if isSystemGroup(groupName) {
+		return nil, fmt.Errorf("membership of system group %s can't be granted", groupName)
+	}
+
+	added, err := k.identities.addGroup(ctx, cluster, principalARN, groupName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add group %s to the mapping of %s: %w", groupName, principalARN, err)
+	}
+	if !added {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+
+	return nil, nil
+}
+
+// Revoke removes the group from the identity mappings of the IAM user or role.
+func (k *kubeGroupBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	principalARN, err := iamPrincipalARN(grant.Principal.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, groupName, err := k.clusters.lookup(grant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if isSystemGroup(groupName) {
+		return nil, fmt.Errorf("membership of system group %s can't be revoked", groupName)
+	}
+
+	removed, err := k.identities.removeGroup(ctx, cluster, principalARN, groupName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove group %s from the mapping of %s: %w", groupName, principalARN, err)
+	}
+	if !removed {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	return nil, nil
+}
+
+// NewKubeGroupBuilder creates a new Kubernetes group builder.
+func NewKubeGroupBuilder(clusters *clusterRegistry, identities *identityMapper) *kubeGroupBuilder {
+	return &kubeGroupBuilder{
+		clusters:   clusters,
+		identities: identities,
+		groups:     newClusterResourceSyncer(k8s.ResourceTypeKubeGroup, clusters),
+	}
+}
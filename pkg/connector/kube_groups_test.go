@@ -0,0 +1,108 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/conductorone/baton-eks/pkg/client"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKubeGroupRegistry() *clusterRegistry {
+	return newTestClusterRegistry(true,
+		withIdentity(&MockEKSClient{
+			usersByGroup: map[string][]string{
+				"developers": {
+					"arn:aws:iam::123456789012:user/alice",
+					"arn:aws:iam::123456789012:role/deployer",
+				},
+				"system:masters": {"arn:aws:iam::123456789012:role/admin"},
+			},
+		}),
+		withInfo(&mockClusterClient{authenticationMode: eksTypes.AuthenticationModeApi}),
+		withAccessEntries(&notFoundAccessEntryClient{mockAccessEntryClient{
+			entries: map[string]*client.AccessEntry{
+				"arn:aws:iam::123456789012:role/deployer": {
+					PrincipalArn:     "arn:aws:iam::123456789012:role/deployer",
+					Type:             "STANDARD",
+					KubernetesGroups: []string{"developers"},
+				},
+			},
+		}}),
+	)
+}
+
+func TestKubeGroupBuilder_AppendMappedGroups(t *testing.T) {
+	registry := newTestKubeGroupRegistry()
+	builder := NewKubeGroupBuilder(registry, newIdentityMapper(false, nil))
+	cluster := registry.all()[0]
+
+	// system:masters is referenced by a binding, and already listed.
+	bound, err := kubeGroupResource("system:masters")
+	require.NoError(t, err)
+	bound = registry.scopeResource(cluster, bound)
+
+	resources, err := builder.appendMappedGroups(context.Background(), cluster, []*v2.Resource{bound})
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "us-east-1/test-cluster/developers", resources[1].Id.Resource)
+	assert.Equal(t, clusterResourceID(cluster), resources[1].ParentResourceId)
+}
+
+func TestKubeGroupBuilder_EntitlementsAndGrants(t *testing.T) {
+	registry := newTestKubeGroupRegistry()
+	builder := NewKubeGroupBuilder(registry, newIdentityMapper(false, nil))
+	cluster := registry.all()[0]
+
+	developers, err := kubeGroupResource("developers")
+	require.NoError(t, err)
+	developers = registry.scopeResource(cluster, developers)
+
+	entitlements, _, _, err := builder.Entitlements(context.Background(), developers, &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, entitlements, 1)
+	assert.Equal(t, kubeGroupEntitlementMember, entitlements[0].Slug)
+	annos := annotations.Annotations(entitlements[0].Annotations)
+	assert.False(t, annos.Contains(&v2.EntitlementImmutable{}))
+
+	grants, _, _, err := builder.Grants(context.Background(), developers, &pagination.Token{})
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	assert.Equal(t, ResourceTypeIAMUser.Id, grants[0].Principal.Id.ResourceType)
+	assert.Equal(t, ResourceTypeIAMRole.Id, grants[1].Principal.Id.ResourceType)
+
+	masters, err := kubeGroupResource("system:masters")
+	require.NoError(t, err)
+	masters = registry.scopeResource(cluster, masters)
+
+	entitlements, _, _, err = builder.Entitlements(context.Background(), masters, &pagination.Token{})
+	require.NoError(t, err)
+	annos = annotations.Annotations(entitlements[0].Annotations)
+	assert.True(t, annos.Contains(&v2.EntitlementImmutable{}))
+
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: ResourceTypeIAMUser.Id, Resource: "arn:aws:iam::123456789012:user/alice"}}
+	_, err = builder.Grant(context.Background(), principal, entitlements[0])
+	assert.Error(t, err)
+}
+
+func TestKubeGroupBuilder_GrantExistingMember(t *testing.T) {
+	registry := newTestKubeGroupRegistry()
+	builder := NewKubeGroupBuilder(registry, newIdentityMapper(false, nil))
+	cluster := registry.all()[0]
+
+	developers, err := kubeGroupResource("developers")
+	require.NoError(t, err)
+	developers = registry.scopeResource(cluster, developers)
+	entitlements, _, _, err := builder.Entitlements(context.Background(), developers, &pagination.Token{})
+	require.NoError(t, err)
+
+	principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: "arn:aws:iam::123456789012:role/deployer"}}
+	annos, err := builder.Grant(context.Background(), principal, entitlements[0])
+	require.NoError(t, err)
+	assert.True(t, annos.Contains(&v2.GrantAlreadyExists{}))
+}
@@ -0,0 +1,122 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// clusterPodIdentities are the pod identity associations of a cluster, by IAM role ARN.
+type clusterPodIdentities struct {
+	byRole map[string][]*client.PodIdentityAssociation
+	expiry time.Time
+}
+
+// podIdentityIndex resolves the service accounts that are associated with IAM roles through EKS Pod Identity.
+type podIdentityIndex struct {
+	clusters  *clusterRegistry
+	mtx       sync.Mutex
+	byCluster map[string]*clusterPodIdentities
+}
+
+// associations returns the cached pod identity associations of the cluster.
+func (x *podIdentityIndex) associations(ctx context.Context, cluster *eksCluster) (*clusterPodIdentities, error) {
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	now := time.Now()
+	if cached, ok := x.byCluster[cluster.id]; ok && now.Before(cached.expiry) {
+		return cached, nil
+	}
+
+	associations, err := cluster.podIdentities.ListPodIdentityAssociations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &clusterPodIdentities{
+		byRole: make(map[string][]*client.PodIdentityAssociation),
+		expiry: now.Add(cacheTTL),
+	}
+	for _, association := range associations {
+		entry.byRole[association.RoleARN] = append(entry.byRole[association.RoleARN], association)
+	}
+
+	x.byCluster[cluster.id] = entry
+	return entry, nil
+}
+
+// serviceAccount returns the association of the service account, if any.
+func (x *podIdentityIndex) serviceAccount(ctx context.Context, cluster *eksCluster, namespace string, name string) (*client.PodIdentityAssociation, error) {
+	cached, err := x.associations(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, associations := range cached.byRole {
+		for _, association := range associations {
+			if association.Namespace == namespace && association.ServiceAccount == name {
+				return association, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// invalidate drops the cached associations of the cluster after they were changed.
+func (x *podIdentityIndex) invalidate(cluster *eksCluster) {
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+	delete(x.byCluster, cluster.id)
+}
+
+// grants returns the grants of the role's entitlement to the service accounts associated with the role.
+func (x *podIdentityIndex) grants(ctx context.Context, resource *v2.Resource, entitlementName string, roleARN string) []*v2.Grant {
+	l := ctxzap.Extract(ctx)
+
+	var rv []*v2.Grant
+	for _, cluster := range x.clusters.all() {
+		cached, err := x.associations(ctx, cluster)
+		if err != nil {
+			// Other clusters can still be resolved.
+			l.Warn("failed to list pod identity associations of cluster",
+				zap.String("cluster", cluster.id),
+				zap.Error(err))
+			continue
+		}
+
+		for _, association := range cached.byRole[roleARN] {
+			saID := x.clusters.scopeID(cluster, fmt.Sprintf("%s/%s", association.Namespace, association.ServiceAccount))
+			rv = append(rv, grant.NewGrant(
+				resource,
+				entitlementName,
+				k8s.GenerateResourceForGrant(saID, k8s.ResourceTypeServiceAccount.Id),
+			))
+		}
+	}
+	return rv
+}
+
+// parseServiceAccountID splits the "namespace/name" ID of a service account.
+func parseServiceAccountID(id string) (string, string, error) {
+	namespace, name, ok := strings.Cut(id, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid service account ID: %s", id)
+	}
+	return namespace, name, nil
+}
+
+func newPodIdentityIndex(clusters *clusterRegistry) *podIdentityIndex {
+	return &podIdentityIndex{
+		clusters:  clusters,
+		byCluster: make(map[string]*clusterPodIdentities),
+	}
+}
@@ -0,0 +1,156 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conductorone/baton-eks/pkg/client"
+	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPodIdentityClient implements the PodIdentityClient interface for testing.
+type mockPodIdentityClient struct {
+	associations []*client.PodIdentityAssociation
+	listCalls    int
+}
+
+func (m *mockPodIdentityClient) ListPodIdentityAssociations(ctx context.Context) ([]*client.PodIdentityAssociation, error) {
+	m.listCalls++
+	return m.associations, nil
+}
+
+func (m *mockPodIdentityClient) CreatePodIdentityAssociation(ctx context.Context, namespace string, serviceAccount string, roleARN string) error {
+	m.associations = append(m.associations, &client.PodIdentityAssociation{
+		AssociationID:  fmt.Sprintf("a-%d", len(m.associations)),
+		Namespace:      namespace,
+		ServiceAccount: serviceAccount,
+		RoleARN:        roleARN,
+	})
+	return nil
+}
+
+func (m *mockPodIdentityClient) DeletePodIdentityAssociation(ctx context.Context, associationID string) error {
+	for idx, association := range m.associations {
+		if association.AssociationID == associationID {
+			m.associations = append(m.associations[:idx], m.associations[idx+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("association %s not found", associationID)
+}
+
+const (
+	testPodIdentityRoleARN  = "arn:aws:iam::123456789012:role/workload"
+	testPodIdentityOtherARN = "arn:aws:iam::123456789012:role/other"
+)
+
+func newTestPodIdentityBuilder(podIdentities *mockPodIdentityClient) *iamRoleBuilder {
+	registry := newTestClusterRegistry(true, withPodIdentities(podIdentities))
+	return NewIAMRoleBuilder(nil, registry, nil)
+}
+
+func testRoleEntitlement() *v2.Entitlement {
+	return &v2.Entitlement{
+		Id: "role:" + testPodIdentityRoleARN + ":assignment",
+		Resource: &v2.Resource{
+			Id: &v2.ResourceId{ResourceType: ResourceTypeIAMRole.Id, Resource: testPodIdentityRoleARN},
+		},
+	}
+}
+
+func testServiceAccount(rawID string) *v2.Resource {
+	return &v2.Resource{
+		Id: &v2.ResourceId{ResourceType: k8s.ResourceTypeServiceAccount.Id, Resource: "us-east-1/test-cluster/" + rawID},
+	}
+}
+
+func TestPodIdentityIndex_Grants(t *testing.T) {
+	podIdentities := &mockPodIdentityClient{
+		associations: []*client.PodIdentityAssociation{
+			{AssociationID: "a-1", Namespace: "default", ServiceAccount: "builder", RoleARN: testPodIdentityRoleARN},
+			{AssociationID: "a-2", Namespace: "ci", ServiceAccount: "runner", RoleARN: testPodIdentityOtherARN},
+		},
+	}
+	builder := newTestPodIdentityBuilder(podIdentities)
+	resource := testRoleEntitlement().Resource
+
+	grants := builder.podIdentity.grants(t.Context(), resource, "assignment", testPodIdentityRoleARN)
+	require.Len(t, grants, 1)
+	assert.Equal(t, k8s.ResourceTypeServiceAccount.Id, grants[0].Principal.Id.ResourceType)
+	assert.Equal(t, "us-east-1/test-cluster/default/builder", grants[0].Principal.Id.Resource)
+
+	// Associations are cached across roles.
+	builder.podIdentity.grants(t.Context(), resource, "assignment", testPodIdentityOtherARN)
+	assert.Equal(t, 1, podIdentities.listCalls)
+}
+
+func TestIAMRoleBuilder_GrantPodIdentity(t *testing.T) {
+	podIdentities := &mockPodIdentityClient{
+		associations: []*client.PodIdentityAssociation{
+			{AssociationID: "a-1", Namespace: "ci", ServiceAccount: "runner", RoleARN: testPodIdentityOtherARN},
+		},
+	}
+	builder := newTestPodIdentityBuilder(podIdentities)
+
+	annos, err := builder.Grant(t.Context(), testServiceAccount("default/builder"), testRoleEntitlement())
+	require.NoError(t, err)
+	assert.Empty(t, annos)
+	require.Len(t, podIdentities.associations, 2)
+	assert.Equal(t, testPodIdentityRoleARN, podIdentities.associations[1].RoleARN)
+
+	annos, err = builder.Grant(t.Context(), testServiceAccount("default/builder"), testRoleEntitlement())
+	require.NoError(t, err)
+	assert.True(t, annos.Contains(&v2.GrantAlreadyExists{}))
+
+	// A service account can only be associated with one role.
+	_, err = builder.Grant(t.Context(), testServiceAccount("ci/runner"), testRoleEntitlement())
+	assert.Error(t, err)
+
+	_, err = builder.Grant(t.Context(), &v2.Resource{
+		Id: &v2.ResourceId{ResourceType: ResourceTypeIAMUser.Id, Resource: "arn:aws:iam::123456789012:user/alice"},
+	}, testRoleEntitlement())
+	assert.Error(t, err)
+}
+
+func TestIAMRoleBuilder_RevokePodIdentity(t *testing.T) {
+	podIdentities := &mockPodIdentityClient{
+		associations: []*client.PodIdentityAssociation{
+			{AssociationID: "a-1", Namespace: "default", ServiceAccount: "builder", RoleARN: testPodIdentityRoleARN},
+			{AssociationID: "a-2", Namespace: "ci", ServiceAccount: "runner", RoleARN: testPodIdentityOtherARN},
+		},
+	}
+	builder := newTestPodIdentityBuilder(podIdentities)
+
+	annos, err := builder.Revoke(t.Context(), &v2.Grant{
+		Entitlement: testRoleEntitlement(),
+		Principal:   testServiceAccount("default/builder"),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, annos)
+	require.Len(t, podIdentities.associations, 1)
+
+	// The service account's association with another role is kept.
+	annos, err = builder.Revoke(t.Context(), &v2.Grant{
+		Entitlement: testRoleEntitlement(),
+		Principal:   testServiceAccount("ci/runner"),
+	})
+	require.NoError(t, err)
+	assert.True(t, annos.Contains(&v2.GrantAlreadyRevoked{}))
+	assert.Len(t, podIdentities.associations, 1)
+}
+
+func TestAppendNewGrants(t *testing.T) {
+	resource := testRoleEntitlement().Resource
+	builder := newTestPodIdentityBuilder(&mockPodIdentityClient{
+		associations: []*client.PodIdentityAssociation{
+			{AssociationID: "a-1", Namespace: "default", ServiceAccount: "builder", RoleARN: testPodIdentityRoleARN},
+		},
+	})
+	grants := builder.podIdentity.grants(t.Context(), resource, "assignment", testPodIdentityRoleARN)
+
+	assert.Len(t, appendNewGrants(grants, grants), 1)
+}
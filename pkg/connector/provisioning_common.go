@@ -188,18 +188,3 @@ func handleRevokeRoleBinding(
 	}
 	return nil, nil
 }
-
-func getOrCreateUsername(ctx context.Context, eksService *client.EKSClient, principal *v2.Resource) (string, error) {
-	iamUserMap, err := eksService.GetMapUserFromAWSAuthConfigMap(ctx, principal.Id.Resource)
-	if err != nil {
-		return "", fmt.Errorf("failed to get map user from aws-auth ConfigMap: %w", err)
-	}
-	if iamUserMap == nil {
-		err = eksService.AddIAMUserMapping(ctx, principal.Id.Resource)
-		if err != nil {
-			return "", fmt.Errorf("failed to add IAM user mapping: %w", err)
-		}
-		return principal.Id.Resource, nil
-	}
-	return iamUserMap.Username, nil
-}
@@ -2,12 +2,21 @@ package connector
 
 import (
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
 )
 
 const resourceTypeIDIAMRole = "role"
 
 // EKS-specific resource types.
 var (
+	ResourceTypeEKSCluster = &v2.ResourceType{
+		Id:          "eks_cluster",
+		DisplayName: "EKS Cluster",
+		Description: "Amazon EKS Cluster",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_APP},
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+	}
+
 	ResourceTypeIAMRole = &v2.ResourceType{
 		Id:          resourceTypeIDIAMRole,
 		DisplayName: "IAM Role",
@@ -20,6 +29,7 @@ var (
 		DisplayName: "IAM User",
 		Description: "AWS IAM User",
 		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
+		Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
 	}
 
 	ResourceTypeAccessPolicy = &v2.ResourceType{
@@ -29,6 +39,13 @@ var (
 		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
 	}
 
+	ResourceTypeAccessEntry = &v2.ResourceType{
+		Id:          "access_entry",
+		DisplayName: "Access Entry",
+		Description: "EKS Access Entry",
+		Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
+	}
+
 	ResourceTypeNamespaceRole = &v2.ResourceType{
 		Id:          "namespace_role",
 		DisplayName: "Namespace Role",
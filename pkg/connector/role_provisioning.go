@@ -17,11 +17,16 @@ func (c *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitle
 		username    string
 	)
 
-	if principal.Id.ResourceType != ResourceTypeIAMUser.Id {
-		return nil, fmt.Errorf("principal type is not supported")
+	principalARN, err := iamPrincipalARN(principal.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	namespace, roleName, err := parseRoleResourceID(entitlement.Resource.Id)
+	cluster, rawID, err := c.clusters.lookup(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	namespace, roleName, err := parseRoleResourceID(rawID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get namespace from entitlement ID: %w", err)
 	}
@@ -30,18 +35,18 @@ func (c *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitle
 		return nil, fmt.Errorf("invalid entitlement ID")
 	}
 
-	username, err = getOrCreateUsername(ctx, c.eksService, principal)
+	username, err = c.identities.getOrCreateUsername(ctx, cluster, principalARN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get or create username: %w", err)
 	}
 
 	// Get matching role bindings for role from the binding provider.
-	matchingBindings, err := c.bindingProvider.GetMatchingRoleBindings(ctx, namespace, roleName)
+	matchingBindings, err := cluster.bindings.GetMatchingRoleBindings(ctx, namespace, roleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matching role bindings: %w", err)
 	}
 
-	annotations, err = handleRoleBinding(ctx, c.eksService, namespace, username, "Role", matchingBindings, roleName)
+	annotations, err = handleRoleBinding(ctx, cluster.eksClient, namespace, username, "Role", matchingBindings, roleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to handle role binding: %w", err)
 	}
@@ -57,7 +62,11 @@ func (c *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 	if grant.Entitlement == nil || grant.Entitlement.Resource == nil || grant.Entitlement.Resource.Id == nil {
 		return nil, fmt.Errorf("invalid grant: missing entitlement resource")
 	}
-	namespace, roleName, err := parseRoleResourceID(grant.Entitlement.Resource.Id)
+	cluster, rawID, err := c.clusters.lookup(grant.Entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	namespace, roleName, err := parseRoleResourceID(rawID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get namespace from entitlement ID: %w", err)
 	}
@@ -67,24 +76,26 @@ func (c *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 		return nil, fmt.Errorf("invalid principal")
 	}
 
-	iamUserMap, err := c.eksService.GetMapUserFromAWSAuthConfigMap(ctx, principal.Id.Resource)
+	username, found, err := c.identities.lookupUsername(ctx, cluster, principal.Id.Resource)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get map user from aws-auth ConfigMap: %w", err)
+		return nil, fmt.Errorf("failed to look up kubernetes username: %w", err)
 	}
 
-	if iamUserMap == nil {
-		l.Debug("no mapping found in aws-auth ConfigMap for the principal, returning GrantAlreadyRevoked",
+	if !found {
+		l.Debug("no kubernetes username mapped for the principal, returning GrantAlreadyRevoked",
 			zap.String("principal", principal.Id.Resource),
 		)
 		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
 	}
 
-	annos, err = c.revokeRoleBinding(ctx, roleName, namespace, iamUserMap.Username)
+	annos, err = c.revokeRoleBinding(ctx, cluster, roleName, namespace, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to revoke role binding: %w", err)
 	}
+	c.identities.releaseUsername(ctx, cluster, principal.Id.Resource, username)
 
 	l.Info("successfully revoked role access",
+		zap.String("cluster", cluster.id),
 		zap.String("role", roleName),
 		zap.String("principal", principal.Id.Resource),
 		zap.String("namespace", namespace),
@@ -94,10 +105,10 @@ func (c *roleBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.
 }
 
 // revokeRoleBinding removes a subject from a RoleBinding.
-func (c *roleBuilder) revokeRoleBinding(ctx context.Context, roleName string, namespace string, username string) (annotations.Annotations, error) {
-	matchingBindings, err := c.bindingProvider.GetMatchingRoleBindings(ctx, namespace, roleName)
+func (c *roleBuilder) revokeRoleBinding(ctx context.Context, cluster *eksCluster, roleName string, namespace string, username string) (annotations.Annotations, error) {
+	matchingBindings, err := cluster.bindings.GetMatchingRoleBindings(ctx, namespace, roleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get matching role bindings: %w", err)
 	}
-	return handleRevokeRoleBinding(ctx, c.eksService, namespace, username, matchingBindings, roleName)
+	return handleRevokeRoleBinding(ctx, cluster.eksClient, namespace, username, matchingBindings, roleName)
 }
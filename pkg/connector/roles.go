@@ -8,9 +8,7 @@ import (
 	k8s "github.com/conductorone/baton-kubernetes/pkg/connector"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 
-	"github.com/conductorone/baton-eks/pkg/client"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
@@ -25,9 +23,8 @@ const roleEntitlementMember = "member"
 
 // roleBuilder syncs Kubernetes Roles as Baton resources.
 type roleBuilder struct {
-	client          kubernetes.Interface
-	bindingProvider k8s.RoleBindingProvider
-	eksService      *client.EKSClient
+	clusters   *clusterRegistry
+	identities *identityMapper
 }
 
 // ResourceType returns the resource type for Role.
@@ -36,10 +33,16 @@ func (r *roleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return ResourceTypeNamespaceRole
 }
 
-// List fetches all Roles from the Kubernetes API.
+// List fetches the Roles of the parent namespace from the Kubernetes API.
 func (r *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
+	// Roles are listed as children of their namespace.
+	cluster, namespace, err := r.clusters.lookupParent(parentResourceID)
+	if err != nil || cluster == nil {
+		return nil, "", nil, err
+	}
+
 	// Initialize empty resource slice.
 	var rv []*v2.Resource
 
@@ -55,9 +58,12 @@ func (r *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 		Continue: bag.PageToken(),
 	}
 
-	// Fetch roles from the Kubernetes API across all namespaces
-	l.Debug("fetching roles", zap.String("continue_token", opts.Continue))
-	resp, err := r.client.RbacV1().Roles("").List(ctx, opts)
+	// Fetch roles of the namespace from the Kubernetes API
+	l.Debug("fetching roles",
+		zap.String("cluster", cluster.id),
+		zap.String("namespace", namespace),
+		zap.String("continue_token", opts.Continue))
+	resp, err := cluster.kube.RbacV1().Roles(namespace).List(ctx, opts)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to list roles: %w", err)
 	}
@@ -72,7 +78,7 @@ func (r *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 				zap.Error(err))
 			continue
 		}
-		rv = append(rv, resource)
+		rv = append(rv, r.clusters.scopeResource(cluster, resource))
 	}
 
 	// Calculate next page token
@@ -139,6 +145,7 @@ func (r *roleBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _
 		entitlement.WithGrantableTo(
 			k8s.ResourceTypeUser,
 			k8s.ResourceTypeGroup,
+			ResourceTypeIAMRole,
 		),
 	)
 	entitlements = append(entitlements, memberEnt)
@@ -146,15 +153,11 @@ func (r *roleBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _
 	return entitlements, "", nil, nil
 }
 
-// parseResourceID extracts namespace and name from a role resource ID.
-func parseRoleResourceID(resourceID *v2.ResourceId) (string, string, error) {
-	if resourceID == nil {
-		return "", "", fmt.Errorf("resource ID is nil")
-	}
-
-	parts := strings.Split(resourceID.Resource, "/")
+// parseRoleResourceID extracts namespace and name from an unscoped role resource ID.
+func parseRoleResourceID(rawID string) (string, string, error) {
+	parts := strings.Split(rawID, "/")
 	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid resource ID format: %s", resourceID.Resource)
+		return "", "", fmt.Errorf("invalid resource ID format: %s", rawID)
 	}
 
 	return parts[0], parts[1], nil
@@ -165,14 +168,21 @@ func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagi
 	l := ctxzap.Extract(ctx)
 	var rv []*v2.Grant
 
-	// Parse the resource ID to get namespace and name.
-	namespace, name, err := parseRoleResourceID(resource.Id)
+	// Parse the resource ID to get the cluster, namespace and name.
+	if resource.Id == nil {
+		return nil, "", nil, fmt.Errorf("resource ID is nil")
+	}
+	cluster, rawID, err := r.clusters.lookup(resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	namespace, name, err := parseRoleResourceID(rawID)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to parse resource ID: %w", err)
 	}
 
 	// Get matching role bindings from the binding provider.
-	matchingBindings, err := r.bindingProvider.GetMatchingRoleBindings(ctx, namespace, name)
+	matchingBindings, err := cluster.bindings.GetMatchingRoleBindings(ctx, namespace, name)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to get matching role bindings: %w", err)
 	}
@@ -183,6 +193,8 @@ func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagi
 		return nil, "", nil, nil
 	}
 
+	users := newUsernameGrants(resource)
+
 	// Process each matching binding.
 	for _, binding := range matchingBindings {
 		// Process each subject in the binding.
@@ -190,7 +202,7 @@ func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagi
 			if subject.Kind == k8s.SubjectKindServiceAccount {
 				// This are Cluster's local service accounts, not Azure.
 				saName := fmt.Sprintf("%s/%s", subject.Namespace, subject.Name)
-				saResource := k8s.GenerateResourceForGrant(saName, k8s.ResourceTypeServiceAccount.Id)
+				saResource := k8s.GenerateResourceForGrant(r.clusters.scopeID(cluster, saName), k8s.ResourceTypeServiceAccount.Id)
 				g := grant.NewGrant(
 					resource,
 					roleEntitlementMember,
@@ -200,32 +212,33 @@ func (r *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, _ *pagi
 			}
 			if (subject.APIGroup == k8s.RBACAPIGroup || subject.APIGroup == k8s.RBACAPIGroupV1) &&
 				!strings.Contains(subject.Name, "system:") {
-				var matchingARNs []string
 				switch subject.Kind {
 				case k8s.SubjectKindGroup:
-					matchingARNs, err = r.eksService.LookupArnsByGroup(ctx, subject.Name)
+					matchingARNs, err := r.identities.groupMembers(ctx, cluster, subject.Name)
 					if err != nil {
 						return nil, "", nil, fmt.Errorf("failed to lookup ARNs for group %s: %w", subject.Name, err)
 					}
+					rv = append(rv, processGrants(matchingARNs, resource, "member")...)
 				case k8s.SubjectKindUser:
-					matchingARNs, err = r.eksService.LookupArnsByUsername(ctx, subject.Name)
+					matches, err := r.identities.usernamePrincipals(ctx, cluster, subject.Name)
 					if err != nil {
 						return nil, "", nil, fmt.Errorf("failed to lookup ARNs for user %s: %w", subject.Name, err)
 					}
+					users.add("member", subject.Name, matches)
 				}
-				rv = append(rv, processGrants(matchingARNs, resource, "member")...)
 			}
 		}
 	}
 
+	rv = append(rv, users.grants()...)
+
 	return rv, "", nil, nil
 }
 
 // newRoleBuilder creates a new role builder.
-func NewRoleBuilder(client kubernetes.Interface, bindingProvider k8s.RoleBindingProvider, provider *client.EKSClient) *roleBuilder {
+func NewRoleBuilder(clusters *clusterRegistry, identities *identityMapper) *roleBuilder {
 	return &roleBuilder{
-		client:          client,
-		bindingProvider: bindingProvider,
-		eksService:      provider,
+		clusters:   clusters,
+		identities: identities,
 	}
 }
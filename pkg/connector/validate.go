@@ -0,0 +1,266 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/conductorone/baton-eks/pkg/client"
+	"google.golang.org/protobuf/types/known/structpb"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validationScope is what stops working when a validation check fails.
+type validationScope string
+
+const (
+	validationScopeSync         validationScope = "sync"
+	validationScopeProvisioning validationScope = "provisioning"
+)
+
+// validationCheck is the outcome of a single validation check.
+type validationCheck struct {
+	// cluster is the ID of the cluster the check ran against.
+	cluster string
+	name    string
+	scope   validationScope
+	err     error
+}
+
+// validationReport collects the outcome of the validation checks, telling apart the failures that break
+// syncs from those that only break provisioning.
+type validationReport struct {
+	callerARN string
+	checks    []validationCheck
+}
+
+func (r *validationReport) add(cluster string, name string, scope validationScope, err error) {
+	r.checks = append(r.checks, validationCheck{cluster: cluster, name: name, scope: scope, err: err})
+}
+
+// failures returns the failed checks of the scope.
+func (r *validationReport) failures(scope validationScope) []validationCheck {
+	var rv []validationCheck
+	for _, check := range r.checks {
+		if check.scope == scope && check.err != nil {
+			rv = append(rv, check)
+		}
+	}
+	return rv
+}
+
+// syncReady reports whether every check that syncs depend on passed.
+func (r *validationReport) syncReady() bool {
+	return len(r.failures(validationScopeSync)) == 0
+}
+
+// provisioningReady reports whether syncs work and every check that provisioning depends on passed.
+func (r *validationReport) provisioningReady() bool {
+	return r.syncReady() && len(r.failures(validationScopeProvisioning)) == 0
+}
+
+// err returns an error listing the failed checks that break syncs, or nil if syncs work.
+func (r *validationReport) err() error {
+	failures := r.failures(validationScopeSync)
+	if len(failures) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(failures))
+	for _, check := range failures {
+		messages = append(messages, check.String())
+	}
+	return fmt.Errorf("eks-connector: validation failed: %s", strings.Join(messages, "; "))
+}
+
+// annotation returns the report as a structured annotation.
+func (r *validationReport) annotation() (*structpb.Struct, error) {
+	failures := make([]interface{}, 0)
+	for _, check := range r.checks {
+		if check.err == nil {
+			continue
+		}
+		failures = append(failures, map[string]interface{}{
+			"cluster": check.cluster,
+			"check":   check.name,
+			"scope":   string(check.scope),
+			"error":   check.err.Error(),
+		})
+	}
+	return structpb.NewStruct(map[string]interface{}{
+		"caller_arn":         r.callerARN,
+		"sync_ready":         r.syncReady(),
+		"provisioning_ready": r.provisioningReady(),
+		"failures":           failures,
+	})
+}
+
+func (c validationCheck) String() string {
+	return fmt.Sprintf("cluster %s: %s: %v", c.cluster, c.name, c.err)
+}
+
+// kubeAccessCheck is a Kubernetes permission the connector needs, checked with a SelfSubjectAccessReview.
+type kubeAccessCheck struct {
+	verb     string
+	group    string
+	resource string
+	// namespace and name narrow the check to a single object; empty checks every namespace.
+	namespace string
+	name      string
+	scope     validationScope
+}
+
+func (c kubeAccessCheck) String() string {
+	resource := c.resource
+	if c.group != "" {
+		resource += "." + c.group
+	}
+	switch {
+	case c.name != "":
+		resource += " " + c.namespace + "/" + c.name
+	case c.namespace != "":
+		resource += " in " + c.namespace
+	}
+	return c.verb + " " + resource
+}
+
+// kubeAccessChecks are the Kubernetes permissions syncs and provisioning need.
+var kubeAccessChecks = []kubeAccessCheck{
+	{verb: "list", group: "rbac.authorization.k8s.io", resource: "clusterroles", scope: validationScopeSync},
+	{verb: "list", group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", scope: validationScopeSync},
+	{verb: "list", group: "rbac.authorization.k8s.io", resource: "roles", scope: validationScopeSync},
+	{verb: "list", group: "rbac.authorization.k8s.io", resource: "rolebindings", scope: validationScopeSync},
+	{verb: "list", resource: "namespaces", scope: validationScopeSync},
+	{verb: "list", resource: "serviceaccounts", scope: validationScopeSync},
+	{verb: "list", resource: "configmaps", scope: validationScopeSync},
+	{verb: "create", group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", scope: validationScopeProvisioning},
+	{verb: "update", group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", scope: validationScopeProvisioning},
+	{verb: "delete", group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", scope: validationScopeProvisioning},
+	{verb: "create", group: "rbac.authorization.k8s.io", resource: "rolebindings", scope: validationScopeProvisioning},
+	{verb: "update", group: "rbac.authorization.k8s.io", resource: "rolebindings", scope: validationScopeProvisioning},
+	{verb: "delete", group: "rbac.authorization.k8s.io", resource: "rolebindings", scope: validationScopeProvisioning},
+}
+
+// awsAuthAccessChecks are the Kubernetes permissions on the aws-auth ConfigMap, needed on clusters that use it.
+var awsAuthAccessChecks = []kubeAccessCheck{
+	{verb: "get", resource: "configmaps", namespace: "kube-system", name: "aws-auth", scope: validationScopeSync},
+	{verb: "update", resource: "configmaps", namespace: "kube-system", name: "aws-auth", scope: validationScopeProvisioning},
+	// The aws-auth ConfigMap is created on clusters that don't have it yet.
+	{verb: "create", resource: "configmaps", namespace: "kube-system", scope: validationScopeProvisioning},
+}
+
+// callerPermissions checks the AWS permissions of the connector's IAM principal.
+type callerPermissions struct {
+	principalARN string
+	// principalErr is why the principal couldn't be told from the caller identity.
+	principalErr error
+	client       client.PermissionClient
+}
+
+// check reports whether the principal is allowed to call the actions on the resource.
+func (p *callerPermissions) check(ctx context.Context, resourceARN string, actions ...string) error {
+	if p.principalErr != nil {
+		return p.principalErr
+	}
+	return p.client.CheckActionsAllowed(ctx, p.principalARN, resourceARN, actions...)
+}
+
+// accessEntryARNs returns the ARN matching every access entry of the cluster with the ARN.
+func accessEntryARNs(clusterARN string) string {
+	return strings.Replace(clusterARN, ":cluster/", ":access-entry/", 1) + "/*"
+}
+
+// validateCluster checks that the cluster can be described, that the connector's Kubernetes identity holds
+// the permissions it needs, and that the aws-auth ConfigMap and access entries can be read and edited on
+// clusters that use them.
+func validateCluster(ctx context.Context, cluster *eksCluster, permissions *callerPermissions, report *validationReport) {
+	description, err := cluster.info.DescribeCluster(ctx)
+	report.add(cluster.id, "describe cluster", validationScopeSync, err)
+
+	// Clusters that don't report an authentication mode predate access entries and only use aws-auth.
+	var mode eksTypes.AuthenticationMode
+	if description != nil && description.AccessConfig != nil {
+		mode = description.AccessConfig.AuthenticationMode
+	}
+	usesAWSAuth := mode == "" || mode == eksTypes.AuthenticationModeConfigMap || mode == eksTypes.AuthenticationModeApiAndConfigMap
+	usesAccessEntries := mode == eksTypes.AuthenticationModeApi || mode == eksTypes.AuthenticationModeApiAndConfigMap
+
+	checks := kubeAccessChecks
+	if usesAWSAuth {
+		checks = append(checks[:len(checks):len(checks)], awsAuthAccessChecks...)
+	}
+	for _, check := range checks {
+		report.add(cluster.id, check.String(), check.scope, reviewKubeAccess(ctx, cluster, check))
+	}
+
+	if usesAccessEntries {
+		report.add(cluster.id, "read access entries", validationScopeSync, cluster.accessEntries.CheckAccessEntryAccess(ctx))
+
+		clusterARN := awsSdk.ToString(description.Arn)
+		report.add(cluster.id, "create access entries", validationScopeProvisioning,
+			permissions.check(ctx, clusterARN, "eks:CreateAccessEntry"))
+		report.add(cluster.id, "associate access policies", validationScopeProvisioning,
+			permissions.check(ctx, accessEntryARNs(clusterARN), "eks:AssociateAccessPolicy", "eks:DisassociateAccessPolicy"))
+	}
+}
+
+// reviewKubeAccess asks the cluster whether the connector's Kubernetes identity holds the permission.
+func reviewKubeAccess(ctx context.Context, cluster *eksCluster, check kubeAccessCheck) error {
+	review, err := cluster.kube.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:      check.verb,
+				Group:     check.group,
+				Resource:  check.resource,
+				Namespace: check.namespace,
+				Name:      check.name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to review access: %w", err)
+	}
+	if !review.Status.Allowed {
+		if review.Status.Reason != "" {
+			return fmt.Errorf("not allowed: %s", review.Status.Reason)
+		}
+		return errors.New("not allowed")
+	}
+	return nil
+}
+
+// validate checks the AWS credentials, then each cluster.
+func (d *Connector) validate(ctx context.Context) (*validationReport, error) {
+	report := &validationReport{}
+
+	callingConfig, err := d.getCallingConfig(ctx, d.config.EksRegion)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := sts.NewFromConfig(callingConfig).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("eks-connector: failed to get caller identity: %w", err)
+	}
+	report.callerARN = awsSdk.ToString(identity.Arn)
+	permissions := d.callerPermissions(ctx, report.callerARN)
+
+	for _, cluster := range d.clusters.all() {
+		validateCluster(ctx, cluster, permissions, report)
+	}
+	return report, nil
+}
+
+// callerPermissions returns the permission checks of the IAM principal of the caller identity. Assumed roles
+// are matched to their role through the account's IAM roles, as their session ARN leaves out the role path.
+func (d *Connector) callerPermissions(ctx context.Context, callerARN string) *callerPermissions {
+	permissions := &callerPermissions{client: d.iamService}
+	permissions.principalARN, permissions.principalErr = client.CallerPrincipalARN(callerARN)
+	if permissions.principalErr == nil {
+		permissions.principalARN, permissions.principalErr = d.iamService.ResolveRoleARN(ctx, permissions.principalARN)
+	}
+	return permissions
+}
@@ -0,0 +1,177 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newAccessReviewKube returns a Kubernetes client whose SelfSubjectAccessReviews allow the verbs in allowed.
+func newAccessReviewKube(t *testing.T, allowed ...string) kubernetes.Interface {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", r.URL.Path)
+		review := &authorizationv1.SelfSubjectAccessReview{}
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(review)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, verb := range allowed {
+			if review.Spec.ResourceAttributes.Verb == verb {
+				review.Status.Allowed = true
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(review))
+	}))
+	t.Cleanup(server.Close)
+
+	kube, err := kubernetes.NewForConfig(&rest.Config{
+		Host:          server.URL,
+		ContentConfig: rest.ContentConfig{ContentType: "application/json"},
+		QPS:           -1,
+	})
+	require.NoError(t, err)
+	return kube
+}
+
+// mockPermissionClient allows every action but the denied ones.
+type mockPermissionClient struct {
+	denied []string
+	checks []string
+}
+
+func (m *mockPermissionClient) CheckActionsAllowed(ctx context.Context, principalARN string, resourceARN string, actions ...string) error {
+	for _, action := range actions {
+		m.checks = append(m.checks, action+" "+resourceARN)
+		for _, denied := range m.denied {
+			if action == denied {
+				return fmt.Errorf("%s isn't allowed %s", principalARN, action)
+			}
+		}
+	}
+	return nil
+}
+
+func newCallerPermissions(denied ...string) *callerPermissions {
+	return &callerPermissions{
+		principalARN: "arn:aws:iam::123456789012:role/baton",
+		client:       &mockPermissionClient{denied: denied},
+	}
+}
+
+func TestValidateCluster(t *testing.T) {
+	cluster := &eksCluster{
+		id:   clusterID("us-east-1", "prod"),
+		kube: newAccessReviewKube(t, "list", "get"),
+		info: &mockClusterClient{authenticationMode: eksTypes.AuthenticationModeApiAndConfigMap},
+		accessEntries: &mockAccessEntryClient{
+			accessErr: errors.New("access denied"),
+		},
+	}
+
+	report := &validationReport{}
+	validateCluster(context.Background(), cluster, newCallerPermissions("eks:CreateAccessEntry"), report)
+
+	assert.False(t, report.syncReady())
+	assert.False(t, report.provisioningReady())
+
+	syncFailures := report.failures(validationScopeSync)
+	require.Len(t, syncFailures, 1)
+	assert.Equal(t, "read access entries", syncFailures[0].name)
+	assert.ErrorContains(t, report.err(), "cluster us-east-1/prod: read access entries: access denied")
+
+	var provisioningFailures []string
+	for _, check := range report.failures(validationScopeProvisioning) {
+		provisioningFailures = append(provisioningFailures, check.name)
+	}
+	assert.Contains(t, provisioningFailures, "update configmaps kube-system/aws-auth")
+	assert.Contains(t, provisioningFailures, "create clusterrolebindings.rbac.authorization.k8s.io")
+	assert.Contains(t, provisioningFailures, "create access entries")
+	assert.NotContains(t, provisioningFailures, "associate access policies")
+	assert.Len(t, provisioningFailures, 9)
+}
+
+func TestValidateCluster_ConfigMapMode(t *testing.T) {
+	cluster := &eksCluster{
+		id:   clusterID("us-east-1", "prod"),
+		kube: newAccessReviewKube(t, "list", "get", "create", "update", "delete"),
+		info: &mockClusterClient{authenticationMode: eksTypes.AuthenticationModeConfigMap},
+		// Access entries aren't read on clusters that only use the aws-auth ConfigMap.
+		accessEntries: &mockAccessEntryClient{
+			accessErr: errors.New("access entries are disabled"),
+		},
+	}
+
+	permissions := newCallerPermissions("eks:CreateAccessEntry")
+	report := &validationReport{}
+	validateCluster(context.Background(), cluster, permissions, report)
+
+	// Nor are the access entry permissions checked.
+	assert.Empty(t, permissions.client.(*mockPermissionClient).checks)
+	assert.True(t, report.syncReady())
+	assert.True(t, report.provisioningReady())
+	assert.NoError(t, report.err())
+
+	annotation, err := report.annotation()
+	require.NoError(t, err)
+	assert.True(t, annotation.Fields["provisioning_ready"].GetBoolValue())
+	assert.Empty(t, annotation.Fields["failures"].GetListValue().GetValues())
+}
+
+func TestValidateCluster_APIMode(t *testing.T) {
+	cluster := &eksCluster{
+		id:   clusterID("us-east-1", "prod"),
+		kube: newAccessReviewKube(t, "list", "create", "update", "delete"),
+		info: &mockClusterClient{
+			authenticationMode: eksTypes.AuthenticationModeApi,
+			arn:                "arn:aws:eks:us-east-1:123456789012:cluster/prod",
+		},
+		accessEntries: &mockAccessEntryClient{},
+	}
+
+	permissions := newCallerPermissions("eks:AssociateAccessPolicy")
+	report := &validationReport{}
+	validateCluster(context.Background(), cluster, permissions, report)
+
+	// aws-auth isn't read on clusters that only use access entries, so it being unreadable doesn't matter.
+	assert.True(t, report.syncReady())
+	for _, check := range report.checks {
+		assert.NotContains(t, check.name, "aws-auth")
+	}
+
+	assert.Equal(t, []string{
+		"eks:CreateAccessEntry arn:aws:eks:us-east-1:123456789012:cluster/prod",
+		"eks:AssociateAccessPolicy arn:aws:eks:us-east-1:123456789012:access-entry/prod/*",
+	}, permissions.client.(*mockPermissionClient).checks)
+	failures := report.failures(validationScopeProvisioning)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "associate access policies", failures[0].name)
+	assert.False(t, report.provisioningReady())
+}
+
+func TestValidateCluster_UnknownCaller(t *testing.T) {
+	cluster := &eksCluster{
+		id:            clusterID("us-east-1", "prod"),
+		kube:          newAccessReviewKube(t, "list", "get", "create", "update", "delete"),
+		info:          &mockClusterClient{authenticationMode: eksTypes.AuthenticationModeApi},
+		accessEntries: &mockAccessEntryClient{},
+	}
+
+	permissions := &callerPermissions{principalErr: errors.New("federated users aren't supported")}
+	report := &validationReport{}
+	validateCluster(context.Background(), cluster, permissions, report)
+
+	assert.True(t, report.syncReady())
+	assert.Len(t, report.failures(validationScopeProvisioning), 2)
+}